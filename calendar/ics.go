@@ -0,0 +1,113 @@
+// Package calendar renders test drives as an iCalendar (RFC 5545) feed that
+// calendar apps can subscribe to. It writes the few properties it needs by
+// hand: CRLF line endings, text escaping and folding of lines longer than 75
+// octets.
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	prodID       = "-//CarZone//Test drives//EN"
+	maxLineBytes = 75
+	timeFormat   = "20060102T150405Z"
+)
+
+// WriteTestDrives renders drives as a calendar named name to w. Cancelled
+// drives are kept with STATUS:CANCELLED so subscribed calendars remove them,
+// and the drive's version is used as SEQUENCE so reschedules replace the
+// earlier copy.
+func WriteTestDrives(w io.Writer, name string, drives []models.TestDrive) error {
+	bw := bufio.NewWriter(w)
+	line := func(prop, value string) {
+		writeFolded(bw, prop+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escape(name))
+
+	stamp := time.Now()
+	for _, d := range drives {
+		status := "CONFIRMED"
+		if d.Status == models.TestDriveCancelled {
+			status = "CANCELLED"
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", d.ID.String()+"@carzone")
+		line("DTSTAMP", formatTime(stamp))
+		line("DTSTART", formatTime(d.StartsAt))
+		line("DTEND", formatTime(d.EndsAt))
+		line("SEQUENCE", strconv.Itoa(d.Version))
+		line("STATUS", status)
+		line("SUMMARY", escape("Test drive: "+d.Car+" with "+d.CustomerName))
+		line("DESCRIPTION", escape(description(d)))
+		line("LAST-MODIFIED", formatTime(d.UpdatedAt))
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+func description(d models.TestDrive) string {
+	parts := []string{"Customer: " + d.CustomerName}
+	if d.CustomerContact != "" {
+		parts = append(parts, "Contact: "+d.CustomerContact)
+	}
+	parts = append(parts, "Salesperson: "+d.Salesperson)
+	if d.Note != "" {
+		parts = append(parts, d.Note)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// escape quotes the characters that are special in TEXT values.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// writeFolded writes one content line, folding it into continuation lines
+// that start with a space so no line exceeds 75 octets. Lines are only
+// broken between UTF-8 sequences.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineBytes
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = maxLineBytes - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
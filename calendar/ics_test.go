@@ -0,0 +1,188 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{`back\slash`, `back\\slash`},
+		{"a;b,c", `a\;b\,c`},
+		{"line one\nline two", `line one\nline two`},
+		{"windows\r\nline", `windows\nline`},
+		{"stray\rreturn", "strayreturn"},
+		{`\n is not a newline`, `\\n is not a newline`},
+		{"colon: stays", "colon: stays"},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func folded(s string) string {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeFolded(w, s)
+	w.Flush()
+	return buf.String()
+}
+
+// unfold reverses folding as RFC 5545 section 3.1 describes: a CRLF
+// followed by a single space is removed.
+func unfold(s string) string {
+	return strings.ReplaceAll(s, "\r\n ", "")
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		lines []string
+	}{
+		{
+			name:  "short line",
+			in:    "SUMMARY:Test drive",
+			lines: []string{"SUMMARY:Test drive"},
+		},
+		{
+			name:  "exactly 75 octets",
+			in:    strings.Repeat("a", 75),
+			lines: []string{strings.Repeat("a", 75)},
+		},
+		{
+			name:  "76 octets",
+			in:    strings.Repeat("a", 76),
+			lines: []string{strings.Repeat("a", 75), " a"},
+		},
+		{
+			name:  "continuation lines hold 74 octets after the space",
+			in:    strings.Repeat("a", 75+74+1),
+			lines: []string{strings.Repeat("a", 75), " " + strings.Repeat("a", 74), " a"},
+		},
+		{
+			// "é" is two octets starting at offset 74, so it may not be
+			// split across the fold.
+			name:  "two-octet character on the boundary",
+			in:    strings.Repeat("a", 74) + "éb",
+			lines: []string{strings.Repeat("a", 74), " éb"},
+		},
+		{
+			// "€" is three octets starting at offset 73.
+			name:  "three-octet character on the boundary",
+			in:    strings.Repeat("a", 73) + "€b",
+			lines: []string{strings.Repeat("a", 73), " €b"},
+		},
+		{
+			name:  "multi-octet character ending on the boundary",
+			in:    strings.Repeat("a", 73) + "éb",
+			lines: []string{strings.Repeat("a", 73) + "é", " b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := folded(tt.in)
+			want := strings.Join(tt.lines, "\r\n") + "\r\n"
+			if got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			if u := unfold(got); u != tt.in+"\r\n" {
+				t.Errorf("unfolds to %q, want %q", u, tt.in)
+			}
+		})
+	}
+}
+
+func TestWriteTestDrives(t *testing.T) {
+	start := time.Date(2024, 5, 10, 9, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	drives := []models.TestDrive{
+		{
+			ID:              uuid.MustParse("33333333-3333-3333-3333-333333333333"),
+			Car:             "Toyota Yaris 2022",
+			CustomerName:    "Budi; Santoso, Jr.",
+			CustomerContact: "+62 812 0000 0000",
+			Salesperson:     "sari",
+			StartsAt:        start,
+			EndsAt:          start.Add(time.Hour),
+			Status:          models.TestDriveScheduled,
+			Note:            strings.Repeat("Wants to try the highway on-ramp – twice. ", 4),
+			UpdatedAt:       start,
+			Version:         3,
+		},
+		{
+			ID:           uuid.MustParse("44444444-4444-4444-4444-444444444444"),
+			Car:          "Honda Jazz 2021",
+			CustomerName: "Ana",
+			Salesperson:  "sari",
+			StartsAt:     start.Add(2 * time.Hour),
+			EndsAt:       start.Add(3 * time.Hour),
+			Status:       models.TestDriveCancelled,
+			UpdatedAt:    start,
+			Version:      1,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTestDrives(&buf, "Test drives: sari", drives); err != nil {
+		t.Fatalf("WriteTestDrives: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Errorf("output does not end with CRLF")
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineBytes {
+			t.Errorf("line %d is %d octets: %q", i+1, len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a UTF-8 sequence: %q", i+1, line)
+		}
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("line %d has a bare line break: %q", i+1, line)
+		}
+	}
+
+	unfolded := strings.Split(unfold(out), "\r\n")
+	for _, want := range []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"X-WR-CALNAME:Test drives: sari",
+		"UID:33333333-3333-3333-3333-333333333333@carzone",
+		"DTSTART:20240510T020000Z",
+		"DTEND:20240510T030000Z",
+		"SEQUENCE:3",
+		"STATUS:CONFIRMED",
+		`SUMMARY:Test drive: Toyota Yaris 2022 with Budi\; Santoso\, Jr.`,
+		`DESCRIPTION:Customer: Budi\; Santoso\, Jr.\nContact: +62 812 0000 0000\nSalesperson: sari\n` +
+			escape(drives[0].Note),
+		"UID:44444444-4444-4444-4444-444444444444@carzone",
+		"STATUS:CANCELLED",
+		`DESCRIPTION:Customer: Ana\nSalesperson: sari`,
+		"END:VCALENDAR",
+	} {
+		found := false
+		for _, line := range unfolded {
+			found = found || line == want
+		}
+		if !found {
+			t.Errorf("missing line %q", want)
+		}
+	}
+
+	if n := strings.Count(out, "BEGIN:VEVENT\r\n"); n != len(drives) {
+		t.Errorf("got %d events, want %d", n, len(drives))
+	}
+}
@@ -0,0 +1,222 @@
+// Package carfile reads and writes car records as CSV and NDJSON (one JSON
+// object per line), the formats used by bulk import and export.
+package carfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Columns is the CSV header, in order.
+var Columns = []string{"name", "brand", "year", "fuel_type", "price", "displacement", "no_of_cylinders", "car_range"}
+
+// OptionalColumns may follow Columns; files written before they existed
+// still import.
+var OptionalColumns = []string{"horsepower", "torque", "transmission", "battery_kwh", "charging_standard", "emissions_class"}
+
+// exportOnlyColumns are written by export and ignored on import, so an
+// exported file can be loaded back as new cars.
+var exportOnlyColumns = map[string]bool{"id": true, "engine_id": true, "status": true, "created_at": true, "updated_at": true}
+
+// columnAliases accepts the JSON field names of CarRequest as CSV headers too.
+var columnAliases = map[string]string{
+	"fueltype":         "fuel_type",
+	"noofcylinders":    "no_of_cylinders",
+	"carrange":         "car_range",
+	"batterykwh":       "battery_kwh",
+	"chargingstandard": "charging_standard",
+	"emissionsclass":   "emissions_class",
+}
+
+var ErrUnsupportedFormat = errors.New("unsupported file format")
+
+// Record is one parsed row. Err is set when the row itself is malformed; the
+// rest of the file is still read.
+type Record struct {
+	Line int
+	Car  models.CarRequest
+	Err  error
+}
+
+// FormatFromContentType maps a request Content-Type to a format.
+func FormatFromContentType(contentType string) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("%w %q (use text/csv or application/x-ndjson)", ErrUnsupportedFormat, contentType)
+}
+
+// FormatFromPath picks the format from a file extension.
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("%w %q (use .csv, .ndjson or .jsonl)", ErrUnsupportedFormat, filepath.Ext(path))
+}
+
+// Read parses r and calls fn for every record. It stops at the first error
+// returned by fn or at a structural problem such as a bad CSV header.
+func Read(format string, r io.Reader, fn func(Record) error) error {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, fn)
+	case FormatNDJSON:
+		return readNDJSON(r, fn)
+	}
+	return fmt.Errorf("%w %q", ErrUnsupportedFormat, format)
+}
+
+func readCSV(r io.Reader, fn func(Record) error) error {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read CSV header: %w", err)
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+		if exportOnlyColumns[name] {
+			continue
+		}
+		if !isColumn(name) {
+			return fmt.Errorf("unknown CSV column %q (expected %s)", header[i], strings.Join(append(Columns, OptionalColumns...), ", "))
+		}
+		index[name] = i
+	}
+	for _, c := range Columns {
+		if _, ok := index[c]; !ok {
+			return fmt.Errorf("CSV header is missing column %q", c)
+		}
+	}
+
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		var rec Record
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			rec = Record{Line: parseErr.StartLine, Err: models.Validation(parseErr.Err.Error())}
+		case err != nil:
+			return err
+		default:
+			rec.Line, _ = cr.FieldPos(0)
+			rec.Car, rec.Err = parseRow(fields, index)
+		}
+
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+func isColumn(name string) bool {
+	for _, c := range append(Columns, OptionalColumns...) {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRow(fields []string, index map[string]int) (models.CarRequest, error) {
+	get := func(name string) string {
+		i, ok := index[name]
+		if !ok {
+			return ""
+		}
+		return unneutralize(strings.TrimSpace(fields[i]))
+	}
+
+	car := models.CarRequest{
+		Name:     get("name"),
+		Brand:    get("brand"),
+		Year:     get("year"),
+		FuelType: get("fuel_type"),
+	}
+	car.Engine.Transmission = get("transmission")
+	car.Engine.ChargingStandard = get("charging_standard")
+	car.Engine.EmissionsClass = get("emissions_class")
+
+	var errs []models.FieldError
+	number := func(name string, parse func(string) error) {
+		if v := get(name); v != "" {
+			if err := parse(v); err != nil {
+				errs = append(errs, models.FieldError{Field: name, Rule: "number", Message: name + " must be a number"})
+			}
+		}
+	}
+	number("price", func(v string) (err error) { car.Price, err = strconv.ParseFloat(v, 64); return })
+	number("displacement", func(v string) (err error) { car.Engine.Displacement, err = strconv.ParseInt(v, 10, 64); return })
+	number("no_of_cylinders", func(v string) (err error) { car.Engine.NoOfCylinders, err = strconv.Atoi(v); return })
+	number("car_range", func(v string) (err error) { car.Engine.CarRange, err = strconv.ParseInt(v, 10, 64); return })
+	number("horsepower", func(v string) (err error) { car.Engine.Horsepower, err = strconv.Atoi(v); return })
+	number("torque", func(v string) (err error) { car.Engine.Torque, err = strconv.Atoi(v); return })
+	number("battery_kwh", func(v string) (err error) { car.Engine.BatteryKWh, err = strconv.ParseFloat(v, 64); return })
+
+	if len(errs) > 0 {
+		return car, models.Validation(errs[0].Message, errs...)
+	}
+	return car, nil
+}
+
+func readNDJSON(r io.Reader, fn func(Record) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		rec := Record{Line: line}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rec.Car); err != nil {
+			rec.Err = models.Validation("invalid JSON: " + err.Error())
+		}
+
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
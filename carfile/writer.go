@@ -0,0 +1,247 @@
+package carfile
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+const FormatXLSX = "xlsx"
+
+// ExportColumns is the header written by export. It is a superset of Columns,
+// so an exported CSV file can be imported again.
+var ExportColumns = []string{
+	"id", "name", "brand", "year", "fuel_type", "price", "status",
+	"engine_id", "displacement", "no_of_cylinders", "car_range",
+	"horsepower", "torque", "transmission", "battery_kwh", "charging_standard", "emissions_class",
+	"created_at", "updated_at",
+}
+
+// Writer encodes cars one at a time. Flush pushes buffered rows to the
+// underlying writer; Close finishes the file.
+type Writer interface {
+	Write(car models.Car) error
+	Flush() error
+	Close() error
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(ExportColumns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("%w %q (use csv, ndjson or xlsx)", ErrUnsupportedFormat, format)
+}
+
+// exportRow returns the ExportColumns values of car. Numbers are returned as
+// float64 so the XLSX writer can store them as numeric cells.
+func exportRow(car models.Car) []any {
+	return []any{
+		car.ID.String(), car.Name, car.Brand, car.Year, car.FuelType, car.Price, car.Status,
+		car.Engine.EngineID.String(), float64(car.Engine.Displacement), float64(car.Engine.NoOfCylinders), float64(car.Engine.CarRange),
+		float64(car.Engine.Horsepower), float64(car.Engine.Torque), car.Engine.Transmission, car.Engine.BatteryKWh,
+		car.Engine.ChargingStandard, car.Engine.EmissionsClass,
+		car.CreatedAt.UTC().Format(time.RFC3339), car.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(car models.Car) error {
+	values := exportRow(car)
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			record[i] = neutralize(v.(string))
+		}
+	}
+	return c.w.Write(record)
+}
+
+// formulaStart holds the characters that make a spreadsheet evaluate a CSV
+// cell as a formula, such as "=HYPERLINK(...)" in a car name.
+const formulaStart = "=+-@\t\r"
+
+// neutralize prefixes a text cell that would run as a formula with ', so
+// spreadsheets show it as text. Text already starting with ' is prefixed
+// too, so that unneutralize only ever removes a quote added here.
+func neutralize(s string) string {
+	if s != "" && strings.IndexByte(formulaStart+"'", s[0]) >= 0 {
+		return "'" + s
+	}
+	return s
+}
+
+// unneutralize undoes neutralize when an exported file is imported again.
+func unneutralize(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.IndexByte(formulaStart+"'", s[1]) >= 0 {
+		return s[1:]
+	}
+	return s
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(car models.Car) error {
+	return n.enc.Encode(car)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.w.Flush()
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.Flush()
+}
+
+// xlsxWriter streams a single-sheet workbook. The zip entries before the
+// sheet are fixed, and the sheet stays open while rows are appended, so
+// nothing but the current row is held in memory. Text is written as inline
+// string cells, which are never evaluated, so it needs no neutralize.
+type xlsxWriter struct {
+	zw    *zip.Writer
+	bw    *bufio.Writer
+	sheet *bufio.Writer
+}
+
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Cars" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	bw := bufio.NewWriter(w)
+	zw := zip.NewWriter(bw)
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxWriter{zw: zw, bw: bw, sheet: bufio.NewWriter(f)}
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]any, len(ExportColumns))
+	for i, c := range ExportColumns {
+		header[i] = c
+	}
+	return x, x.writeRow(header)
+}
+
+func (x *xlsxWriter) Write(car models.Car) error {
+	return x.writeRow(exportRow(car))
+}
+
+func (x *xlsxWriter) writeRow(values []any) error {
+	x.sheet.WriteString("<row>")
+	for _, v := range values {
+		switch v := v.(type) {
+		case float64:
+			x.sheet.WriteString(`<c><v>` + strconv.FormatFloat(v, 'f', -1, 64) + `</v></c>`)
+		case string:
+			x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(x.sheet, []byte(v)); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString("</row>")
+	return err
+}
+
+// Flush pushes the compressed bytes produced so far. Deflate keeps a window
+// of recent input, so some rows may only appear after Close.
+func (x *xlsxWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	if err := x.zw.Flush(); err != nil {
+		return err
+	}
+	return x.bw.Flush()
+}
+
+func (x *xlsxWriter) Close() error {
+	x.sheet.WriteString("</sheetData></worksheet>")
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	if err := x.zw.Close(); err != nil {
+		return err
+	}
+	return x.bw.Flush()
+}
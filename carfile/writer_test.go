@@ -0,0 +1,267 @@
+package carfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+)
+
+func testCar() models.Car {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("WIB", 7*3600))
+	return models.Car{
+		ID:       uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		Name:     `Civic <Type R> & "Sport"`,
+		Brand:    "Honda",
+		Year:     "2023",
+		FuelType: "Petrol",
+		Price:    850000000.5,
+		Status:   models.StatusAvailable,
+		Engine: models.Engine{
+			EngineID:      uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+			Displacement:  1996,
+			NoOfCylinders: 4,
+			CarRange:      600,
+			Horsepower:    315,
+			Transmission:  "manual",
+		},
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+}
+
+// sheetCell is a cell of the worksheet as written by xlsxWriter: a number
+// in v or an inline string in is/t.
+type sheetCell struct {
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Cells []sheetCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX unzips the workbook and returns its parts by name.
+func readXLSX(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip file: %v", err)
+	}
+	parts := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		parts[f.Name] = body
+	}
+	return parts
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	car := testCar()
+	car.Brand = "=Honda"
+	if err := w.Write(car); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	parts := readXLSX(t, buf.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	var sheet worksheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("sheet is not well-formed XML: %v", err)
+	}
+	if len(sheet.Rows) != 2 {
+		t.Fatalf("got %d rows, want header and one car", len(sheet.Rows))
+	}
+
+	var header []string
+	for _, c := range sheet.Rows[0].Cells {
+		header = append(header, c.Inline)
+	}
+	if !reflect.DeepEqual(header, ExportColumns) {
+		t.Errorf("header = %v, want %v", header, ExportColumns)
+	}
+
+	row := sheet.Rows[1].Cells
+	if len(row) != len(ExportColumns) {
+		t.Fatalf("got %d cells, want %d", len(row), len(ExportColumns))
+	}
+	cell := func(column string) sheetCell {
+		for i, c := range ExportColumns {
+			if c == column {
+				return row[i]
+			}
+		}
+		t.Fatalf("unknown column %s", column)
+		return sheetCell{}
+	}
+
+	texts := map[string]string{
+		"id":           car.ID.String(),
+		"name":         car.Name,
+		"brand":        "=Honda", // inline strings are never evaluated
+		"year":         "2023",
+		"transmission": "manual",
+		"created_at":   "2024-03-01T02:30:00Z",
+	}
+	for column, want := range texts {
+		c := cell(column)
+		if c.Type != "inlineStr" || c.Inline != want {
+			t.Errorf("%s = %+v, want inline string %q", column, c, want)
+		}
+	}
+
+	numbers := map[string]string{
+		"price":           "850000000.5",
+		"displacement":    "1996",
+		"no_of_cylinders": "4",
+		"horsepower":      "315",
+		"battery_kwh":     "0",
+	}
+	for column, want := range numbers {
+		c := cell(column)
+		if c.Type != "" || c.Value != want {
+			t.Errorf("%s = %+v, want number %s", column, c, want)
+		}
+	}
+}
+
+func TestXLSXWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var sheet worksheet
+	if err := xml.Unmarshal(readXLSX(t, buf.Bytes())["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("sheet is not well-formed XML: %v", err)
+	}
+	if len(sheet.Rows) != 1 {
+		t.Errorf("got %d rows, want only the header", len(sheet.Rows))
+	}
+}
+
+// An exported CSV file loads back as the same cars, including names that
+// were neutralized so spreadsheets do not run them as formulas.
+func TestCSVWriterRoundTrip(t *testing.T) {
+	tests := []struct {
+		name, brand string
+		// cell is the name as written to the file.
+		cell string
+	}{
+		{name: `Civic <Type R> & "Sport"`, brand: "Honda", cell: `Civic <Type R> & "Sport"`},
+		{name: `=HYPERLINK("http://x.test","Civic")`, brand: "Honda", cell: `'=HYPERLINK("http://x.test","Civic")`},
+		{name: "+62 Edition", brand: "-Brand", cell: "'+62 Edition"},
+		{name: "@SUM(A1:A9)", brand: "Honda", cell: "'@SUM(A1:A9)"},
+		{name: "'67 Mustang", brand: "Ford", cell: "''67 Mustang"},
+		{name: "'=already quoted", brand: "Ford", cell: "''=already quoted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			car := testCar()
+			car.Name, car.Brand = tt.name, tt.brand
+
+			var buf bytes.Buffer
+			w, err := NewWriter(FormatCSV, &buf)
+			if err != nil {
+				t.Fatalf("NewWriter: %v", err)
+			}
+			if err := w.Write(car); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+			if err != nil {
+				t.Fatalf("output is not CSV: %v", err)
+			}
+			if got := rows[1][1]; got != tt.cell {
+				t.Errorf("name cell = %q, want %q", got, tt.cell)
+			}
+
+			var records []Record
+			err = Read(FormatCSV, &buf, func(r Record) error {
+				records = append(records, r)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if len(records) != 1 || records[0].Err != nil {
+				t.Fatalf("got records %+v", records)
+			}
+			got := records[0].Car
+			if got.Name != car.Name || got.Brand != car.Brand || got.Price != car.Price ||
+				got.Engine.Displacement != car.Engine.Displacement || got.Engine.Horsepower != car.Engine.Horsepower {
+				t.Errorf("got %+v, want the fields of %+v", got, car)
+			}
+		})
+	}
+}
+
+func TestNeutralize(t *testing.T) {
+	for _, s := range []string{"=1+1", "+1", "-1", "@A1", "\tx", "\rx", "'x", "'=x"} {
+		if got := neutralize(s); got != "'"+s {
+			t.Errorf("neutralize(%q) = %q, want it prefixed with '", s, got)
+		}
+		if got := unneutralize(neutralize(s)); got != s {
+			t.Errorf("unneutralize(neutralize(%q)) = %q", s, got)
+		}
+	}
+	for _, s := range []string{"", "Civic", "1=1", "a-b", "67 Mustang"} {
+		if got := neutralize(s); got != s {
+			t.Errorf("neutralize(%q) = %q, want it unchanged", s, got)
+		}
+	}
+}
+
+func TestNewWriterUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("xls", io.Discard)
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("got error %v, want %v", err, ErrUnsupportedFormat)
+	}
+	if !strings.Contains(err.Error(), "xlsx") {
+		t.Errorf("error %q does not list the supported formats", err)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/KRAZYFLASH/carZone/carfile"
+	"github.com/KRAZYFLASH/carZone/models"
+	auditService "github.com/KRAZYFLASH/carZone/service/audit"
+	carService "github.com/KRAZYFLASH/carZone/service/car"
+	"github.com/KRAZYFLASH/carZone/store"
+	auditStore "github.com/KRAZYFLASH/carZone/store/audit"
+	carStore "github.com/KRAZYFLASH/carZone/store/car"
+	"github.com/KRAZYFLASH/carZone/store/migrations"
+)
+
+const usage = `usage:
+  carzone [serve]                start the HTTP server
+  carzone migrate up             apply all pending migrations
+  carzone migrate down [steps]   revert the last migration (or the last N)
+  carzone migrate status         list migrations and whether they are applied
+  carzone seed                   load the sample data set
+  carzone import [-mode best_effort] <file.csv|file.ndjson>
+                                 bulk-load cars (default mode all_or_nothing)`
+
+func runCommand(ctx context.Context, db *sql.DB, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(ctx, db, args[1:])
+	case "seed":
+		m, err := migrations.New(db)
+		if err != nil {
+			return err
+		}
+		if err := m.Seed(ctx); err != nil {
+			return err
+		}
+		fmt.Println("seed data loaded")
+		return nil
+	case "import":
+		return runImport(ctx, db, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runImport(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	mode := fs.String("mode", string(models.ImportAllOrNothing), "all_or_nothing or best_effort")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(usage)
+	}
+	path := fs.Arg(0)
+
+	format, err := carfile.FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var records []carfile.Record
+	err = carfile.Read(format, f, func(rec carfile.Record) error {
+		records = append(records, rec)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	csvc := carService.NewCarService(carStore.New(db), store.NewTransactor(db), auditService.NewAuditService(auditStore.New(db)))
+	report, err := csvc.ImportCars(ctx, records, models.ImportMode(*mode))
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		if row.Status == models.ImportRowCreated {
+			continue
+		}
+		fmt.Printf("line %d: %s", row.Line, row.Status)
+		if row.Error != "" {
+			fmt.Printf(": %s", row.Error)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("imported %d of %d rows (%d failed)\n", report.Created, report.Total, report.Failed)
+	if !report.Committed {
+		return errors.New("nothing was imported")
+	}
+	return nil
+}
+
+func runMigrate(ctx context.Context, db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	m, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("applied  %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", st.Version, st.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type AuditHandler struct {
+	service service.AuditServiceInterface
+}
+
+func NewAuditHandler(service service.AuditServiceInterface) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// ListAudit serves GET /audit?entity=car&id=...&limit=...&cursor=..., newest
+// entries first.
+func (h *AuditHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("AuditHandler")
+	ctx, span := tracer.Start(r.Context(), "ListAudit-Handler")
+	defer span.End()
+
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.ListAudit(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+func parseAuditFilter(q url.Values) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		EntityType: q.Get("entity"),
+		Cursor:     q.Get("cursor"),
+	}
+
+	valid := false
+	for _, e := range models.AuditEntities {
+		valid = valid || e == filter.EntityType
+	}
+	if !valid {
+		return filter, handler.InvalidParam("entity", "must be one of the following: "+strings.Join(models.AuditEntities, ", "))
+	}
+
+	if id := q.Get("id"); id != "" {
+		entityID, err := uuid.Parse(id)
+		if err != nil {
+			return filter, handler.InvalidParam("id", "must be a valid UUID")
+		}
+		filter.EntityID = &entityID
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxPageSize {
+			return filter, handler.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", models.MaxPageSize))
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,35 @@
+package car
+
+import (
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"go.opentelemetry.io/otel"
+)
+
+// BatchCars serves POST /cars/batch. The response is the per-operation
+// report, with 422 when the batch was rolled back.
+func (h *CarHandler) BatchCars(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "BatchCars-Handler")
+	defer span.End()
+
+	var req models.BatchRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	report, err := h.service.BatchCars(ctx, req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !report.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+	handler.WriteJSON(w, status, report)
+}
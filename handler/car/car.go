@@ -1,14 +1,12 @@
 package car
 
 import (
-	"encoding/json"
-	"io"
-	"log"
 	"net/http"
+	"time"
 
+	"github.com/KRAZYFLASH/carZone/handler"
 	"github.com/KRAZYFLASH/carZone/models"
 	"github.com/KRAZYFLASH/carZone/service"
-	"github.com/gorilla/mux"
 	"go.opentelemetry.io/otel"
 )
 
@@ -25,65 +23,109 @@ func (h *CarHandler) GetCarById(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "GetCarById-Handler")
 	defer span.End()
 
-	vars := mux.Vars(r)
-	id := vars["id"] 
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
-	resp, err := h.service.GetCarById(ctx, id)
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		t, err := time.Parse(time.RFC3339, asOf)
+		if err != nil {
+			handler.WriteError(w, handler.InvalidParam("as_of", "must be an RFC 3339 timestamp"))
+			return
+		}
+		resp, err := h.service.GetCarAsOf(ctx, id, t)
+		if err != nil {
+			handler.WriteError(w, err)
+			return
+		}
+		handler.WriteJSON(w, http.StatusOK, resp)
+		return
+	}
 
+	resp, err := h.service.GetCarById(ctx, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println("Error fetching car by ID:", err)
+		handler.WriteError(w, err)
 		return
 	}
+	if handler.NotModified(w, r, resp.Version) {
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
 
-	body, err := json.Marshal(resp)
+func (h *CarHandler) ListCars(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "ListCars-Handler")
+	defer span.End()
+
+	filter, err := parseCarFilter(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println("Error marshalling response:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	
-
-	// Write the response body
-	_, err = w.Write(body)
+	resp, err := h.service.ListCars(ctx, filter)
 	if err != nil {
-		log.Println("Error writing response:", err)
+		handler.WriteError(w, err)
+		return
 	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
 }
 
-func (h *CarHandler) GetCarByBrand(w http.ResponseWriter, r *http.Request) {
+// ListEngineCars serves GET /engine/{id}/cars, the live cars using an
+// engine. It accepts the same filters, sort and cursor as ListCars.
+func (h *CarHandler) ListEngineCars(w http.ResponseWriter, r *http.Request) {
 	tracer := otel.Tracer("CarHandler")
-	ctx, span := tracer.Start(r.Context(), "GetCarByBrand-Handler")
+	ctx, span := tracer.Start(r.Context(), "ListEngineCars-Handler")
 	defer span.End()
 
-	brand := r.URL.Query().Get("brand")
-	isEngine := r.URL.Query().Get("isEngine") == "true"
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
-	resp, err := h.service.GetCarByBrand(ctx, brand, isEngine)
+	filter, err := parseCarFilter(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println("Error fetching car by brand:", err)
+		handler.WriteError(w, err)
 		return
 	}
+	filter.EngineID = id
 
-	body, err := json.Marshal(resp)
+	resp, err := h.service.ListCars(ctx, filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println("Error marshalling response:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListTrash lists soft-deleted cars. It accepts the same filters, sort and
+// cursor as ListCars.
+func (h *CarHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "ListTrash-Handler")
+	defer span.End()
 
-	// Write the response body
-	_, err = w.Write(body)
+	filter, err := parseCarFilter(r.URL.Query())
 	if err != nil {
-		log.Println("Error writing response:", err)
+		handler.WriteError(w, err)
+		return
 	}
+	filter.Deleted = true
+
+	resp, err := h.service.ListCars(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
 }
 
 func (h *CarHandler) CreateCar(w http.ResponseWriter, r *http.Request) {
@@ -91,119 +133,183 @@ func (h *CarHandler) CreateCar(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "CreateCar-Handler")
 	defer span.End()
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		log.Println("Error reading request body:", err)
+	var carReq models.CarRequest
+	if err := handler.DecodeJSON(r, &carReq); err != nil {
+		handler.WriteError(w, err)
 		return
 	}
 
-	var carReq models.CarRequest
-
-	if err := json.Unmarshal(body, &carReq); err != nil {
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
-		log.Println("Error unmarshalling request body:", err)
+	createdCar, err := h.service.CreateCar(ctx, &carReq)
+	if err != nil {
+		handler.WriteError(w, err)
 		return
 	}
 
-	createdCar, err := h.service.CreateCar(ctx, &carReq)
+	handler.WriteJSON(w, http.StatusCreated, createdCar)
+}
+
+func (h *CarHandler) UpdateCar(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateCar-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println("Error creating car:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	responseBody, err := json.Marshal(createdCar)
+	match, err := handler.IfMatch(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling response:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	_, err = w.Write(responseBody)
+	var carReq models.CarRequest
+	if err := handler.DecodeJSON(r, &carReq); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
+	updatedCar, err := h.service.UpdateCar(ctx, id, &carReq, match)
 	if err != nil {
-		log.Println("Error writing response:", err)
+		handler.WriteError(w, err)
+		return
 	}
+
+	w.Header().Set("ETag", handler.ETag(updatedCar.Version))
+	handler.WriteJSON(w, http.StatusOK, updatedCar)
 }
 
-func (h *CarHandler) UpdateCar(w http.ResponseWriter, r *http.Request) {
+func (h *CarHandler) PatchCar(w http.ResponseWriter, r *http.Request) {
 	tracer := otel.Tracer("CarHandler")
-	ctx, span := tracer.Start(r.Context(), "UpdateCar-Handler")
+	ctx, span := tracer.Start(r.Context(), "PatchCar-Handler")
 	defer span.End()
 
-	vars := mux.Vars(r)
-	id := vars["id"]
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
-	body, err := io.ReadAll(r.Body)
+	match, err := handler.IfMatch(r)
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		log.Println("Error reading request body:", err)
-		w.WriteHeader(http.StatusBadRequest)
+		handler.WriteError(w, err)
 		return
 	}
 
-	var carReq models.CarRequest
-	err = json.Unmarshal(body, &carReq)
+	patch, err := handler.DecodePatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	patchedCar, err := h.service.PatchCar(ctx, id, patch, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(patchedCar.Version))
+	handler.WriteJSON(w, http.StatusOK, patchedCar)
+}
+
+func (h *CarHandler) DeleteCar(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteCar-Handler")
+	defer span.End()
 
+	id, err := handler.PathID(r)
 	if err != nil {
-		log.Println("Error unmarshalling request body:", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		handler.WriteError(w, err)
 		return
 	}
 
-	updatedCar, err := h.service.UpdateCar(ctx, id, &carReq)
+	match, err := handler.IfMatch(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println("Error updating car:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	resBody, err := json.Marshal(updatedCar)
+	deletedCar, err := h.service.DeleteCar(ctx, id, match)
 	if err != nil {
-		log.Println("Error marshalling response:", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		handler.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	handler.WriteJSON(w, http.StatusOK, deletedCar)
+}
+
+func (h *CarHandler) RestoreCar(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "RestoreCar-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
-	_, err = w.Write(resBody)
+	restoredCar, err := h.service.RestoreCar(ctx, id)
 	if err != nil {
-		log.Println("Error writing response:", err)
+		handler.WriteError(w, err)
+		return
 	}
 
+	w.Header().Set("ETag", handler.ETag(restoredCar.Version))
+	handler.WriteJSON(w, http.StatusOK, restoredCar)
 }
 
-func (h *CarHandler) DeleteCar(w http.ResponseWriter, r *http.Request) {
+func (h *CarHandler) ListCarHistory(w http.ResponseWriter, r *http.Request) {
 	tracer := otel.Tracer("CarHandler")
-	ctx, span := tracer.Start(r.Context(), "DeleteCar-Handler")
+	ctx, span := tracer.Start(r.Context(), "ListCarHistory-Handler")
 	defer span.End()
 
-	vars := mux.Vars(r)
-	id := vars["id"]
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
-	deletedCar, err := h.service.DeleteCar(ctx, id)
+	revisions, err := h.service.ListCarHistory(ctx, id)
 	if err != nil {
-		log.Println("Error deleting car:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	handler.WriteJSON(w, http.StatusOK, map[string]any{"revisions": revisions})
+}
+
+func (h *CarHandler) RevertCar(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "RevertCar-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
-	resBody, err := json.Marshal(deletedCar)
+	match, err := handler.IfMatch(r)
 	if err != nil {
-		log.Println("Error marshalling response:", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		handler.WriteError(w, err)
 		return
 	}
 
-	_, err = w.Write(resBody)
+	var req models.RevertRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	revertedCar, err := h.service.RevertCar(ctx, id, req.Version, match)
 	if err != nil {
-		log.Println("Error writing response:", err)
+		handler.WriteError(w, err)
+		return
 	}
-}
\ No newline at end of file
+
+	w.Header().Set("ETag", handler.ETag(revertedCar.Version))
+	handler.WriteJSON(w, http.StatusOK, revertedCar)
+}
@@ -0,0 +1,86 @@
+package car
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/carfile"
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"go.opentelemetry.io/otel"
+)
+
+// exportFlushRows is how often buffered rows are pushed to the client.
+const exportFlushRows = 500
+
+// ExportCars serves GET /cars/export?format=csv|ndjson|xlsx with the same
+// filters and sort as ListCars. Rows are written as they are read, so once
+// the first row is out an error can only abort the response.
+func (h *CarHandler) ExportCars(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "ExportCars-Handler")
+	defer span.End()
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = carfile.FormatCSV
+	}
+	if format != carfile.FormatCSV && format != carfile.FormatNDJSON && format != carfile.FormatXLSX {
+		handler.WriteError(w, handler.InvalidParam("format", "must be one of the following: csv, ndjson, xlsx"))
+		return
+	}
+
+	filter, err := parseCarFilter(q)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var (
+		out     carfile.Writer
+		written int
+	)
+	start := func() error {
+		w.Header().Set("Content-Type", carfile.ContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="cars.`+format+`"`)
+		out, err = carfile.NewWriter(format, w)
+		return err
+	}
+
+	err = h.service.ExportCars(ctx, filter, func(car models.Car) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := out.Write(car); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushRows == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		return nil
+	})
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err != nil {
+		if out == nil {
+			handler.WriteError(w, err)
+			return
+		}
+		log.Printf("export aborted after %d rows: %v", written, err)
+		return
+	}
+
+	if err := out.Close(); err != nil {
+		log.Printf("export: finishing file: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package car
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+)
+
+// parseCarFilter reads the listing query string, e.g.
+// ?brand=Honda&year_min=2020&price_max=30000&sort=-price,name&limit=50&cursor=...
+func parseCarFilter(q url.Values) (models.CarFilter, error) {
+	filter := models.CarFilter{
+		Brand:    q.Get("brand"),
+		FuelType: q.Get("fuel_type"),
+		Name:     q.Get("name"),
+		EngineID: q.Get("engine_id"),
+		Cursor:   q.Get("cursor"),
+	}
+	if filter.EngineID != "" {
+		if _, err := uuid.Parse(filter.EngineID); err != nil {
+			return filter, handler.InvalidParam("engine_id", "must be a valid UUID")
+		}
+	}
+	if status := q.Get("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			s = strings.TrimSpace(s)
+			if !models.IsCarStatus(s) {
+				return filter, handler.InvalidParam("status", "must be one of the following: "+strings.Join(models.CarStatuses, ", "))
+			}
+			filter.Statuses = append(filter.Statuses, s)
+		}
+	}
+
+	var err error
+	if filter.YearMin, err = handler.IntParam(q, "year_min"); err != nil {
+		return filter, err
+	}
+	if filter.YearMax, err = handler.IntParam(q, "year_max"); err != nil {
+		return filter, err
+	}
+	if filter.PriceMin, err = handler.FloatParam(q, "price_min"); err != nil {
+		return filter, err
+	}
+	if filter.PriceMax, err = handler.FloatParam(q, "price_max"); err != nil {
+		return filter, err
+	}
+	if filter.DisplacementMin, err = handler.Int64Param(q, "displacement_min"); err != nil {
+		return filter, err
+	}
+	if filter.DisplacementMax, err = handler.Int64Param(q, "displacement_max"); err != nil {
+		return filter, err
+	}
+	if filter.CylindersMin, err = handler.IntParam(q, "cylinders_min"); err != nil {
+		return filter, err
+	}
+	if filter.CylindersMax, err = handler.IntParam(q, "cylinders_max"); err != nil {
+		return filter, err
+	}
+	if filter.RangeMin, err = handler.Int64Param(q, "range_min"); err != nil {
+		return filter, err
+	}
+	if filter.RangeMax, err = handler.Int64Param(q, "range_max"); err != nil {
+		return filter, err
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxPageSize {
+			return filter, handler.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", models.MaxPageSize))
+		}
+		filter.Limit = n
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			sf := models.SortField{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(sf.Field, "-") {
+				sf.Field, sf.Desc = sf.Field[1:], true
+			}
+			if !models.IsCarSortField(sf.Field) {
+				return filter, handler.InvalidParam("sort", fmt.Sprintf("cannot sort by %q; allowed: %s", sf.Field, strings.Join(models.CarSortFields, ", ")))
+			}
+			filter.Sort = append(filter.Sort, sf)
+		}
+	}
+
+	return filter, nil
+}
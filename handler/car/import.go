@@ -0,0 +1,61 @@
+package car
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/carfile"
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"go.opentelemetry.io/otel"
+)
+
+const maxImportBytes = 32 << 20
+
+// ImportCars serves POST /cars/import?mode=all_or_nothing|best_effort with a
+// text/csv or application/x-ndjson body. The response is the per-row report,
+// with 422 when nothing was saved.
+func (h *CarHandler) ImportCars(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "ImportCars-Handler")
+	defer span.End()
+
+	mode := models.ImportMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = models.ImportAllOrNothing
+	}
+
+	format, err := carfile.FormatFromContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		handler.WriteError(w, models.UnsupportedMediaType(err.Error()))
+		return
+	}
+
+	var records []carfile.Record
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	err = carfile.Read(format, body, func(rec carfile.Record) error {
+		records = append(records, rec)
+		return nil
+	})
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		handler.WriteError(w, models.BadRequest("import body is larger than 32 MB"))
+		return
+	}
+	if err != nil {
+		handler.WriteError(w, models.BadRequest(err.Error()))
+		return
+	}
+
+	report, err := h.service.ImportCars(ctx, records, mode)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !report.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+	handler.WriteJSON(w, status, report)
+}
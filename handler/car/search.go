@@ -0,0 +1,37 @@
+package car
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"go.opentelemetry.io/otel"
+)
+
+// SearchCars serves GET /cars/search?q=civic+2023+hybrid&limit=20.
+func (h *CarHandler) SearchCars(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "SearchCars-Handler")
+	defer span.End()
+
+	q := r.URL.Query()
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > models.MaxPageSize {
+			handler.WriteError(w, handler.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", models.MaxPageSize)))
+			return
+		}
+		limit = n
+	}
+
+	resp, err := h.service.SearchCars(ctx, q.Get("q"), limit)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,69 @@
+package car
+
+import (
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"go.opentelemetry.io/otel"
+)
+
+// TransitionCar returns the handler for POST /cars/{id}/<action>, e.g.
+// /cars/{id}/withdraw. The body may carry a note; If-Match is honoured.
+func (h *CarHandler) TransitionCar(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tracer := otel.Tracer("CarHandler")
+		ctx, span := tracer.Start(r.Context(), "TransitionCar-Handler")
+		defer span.End()
+
+		id, err := handler.PathID(r)
+		if err != nil {
+			handler.WriteError(w, err)
+			return
+		}
+
+		match, err := handler.IfMatch(r)
+		if err != nil {
+			handler.WriteError(w, err)
+			return
+		}
+
+		var req models.TransitionRequest
+		if r.ContentLength != 0 {
+			if err := handler.DecodeJSON(r, &req); err != nil {
+				handler.WriteError(w, err)
+				return
+			}
+		}
+
+		car, err := h.service.TransitionCar(ctx, id, action, req.Note, match)
+		if err != nil {
+			handler.WriteError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", handler.ETag(car.Version))
+		handler.WriteJSON(w, http.StatusOK, car)
+	}
+}
+
+// ListStatusChanges serves GET /cars/{id}/status-history.
+func (h *CarHandler) ListStatusChanges(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "ListStatusChanges-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	changes, err := h.service.ListStatusChanges(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, map[string]any{"changes": changes})
+}
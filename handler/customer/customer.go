@@ -0,0 +1,218 @@
+package customer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type CustomerHandler struct {
+	service service.CustomerServiceInterface
+}
+
+func NewCustomerHandler(service service.CustomerServiceInterface) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CustomerHandler")
+	ctx, span := tracer.Start(r.Context(), "GetCustomer-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.GetCustomer(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	if handler.NotModified(w, r, resp.Version) {
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListCustomers serves GET /customers?q=&car_id=, ordered by name. q matches
+// name, email or phone; car_id lists the customers interested in that car.
+func (h *CustomerHandler) ListCustomers(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CustomerHandler")
+	ctx, span := tracer.Start(r.Context(), "ListCustomers-Handler")
+	defer span.End()
+
+	filter, err := parseCustomerFilter(r.URL.Query())
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.ListCustomers(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CustomerHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateCustomer-Handler")
+	defer span.End()
+
+	var req models.CustomerRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	created, err := h.service.CreateCustomer(ctx, &req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(created.Version))
+	handler.WriteJSON(w, http.StatusCreated, created)
+}
+
+func (h *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CustomerHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateCustomer-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.CustomerRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	updated, err := h.service.UpdateCustomer(ctx, id, &req, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(updated.Version))
+	handler.WriteJSON(w, http.StatusOK, updated)
+}
+
+func (h *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CustomerHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteCustomer-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	deleted, err := h.service.DeleteCustomer(ctx, id, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, deleted)
+}
+
+// ListNotes serves GET /customers/{id}/notes, oldest first.
+func (h *CustomerHandler) ListNotes(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CustomerHandler")
+	ctx, span := tracer.Start(r.Context(), "ListNotes-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	notes, err := h.service.ListNotes(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, map[string]any{"notes": notes})
+}
+
+func (h *CustomerHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CustomerHandler")
+	ctx, span := tracer.Start(r.Context(), "AddNote-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.CustomerNoteRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	note, err := h.service.AddNote(ctx, id, &req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusCreated, note)
+}
+
+func parseCustomerFilter(q url.Values) (models.CustomerFilter, error) {
+	filter := models.CustomerFilter{
+		Query:  q.Get("q"),
+		CarID:  q.Get("car_id"),
+		Cursor: q.Get("cursor"),
+	}
+
+	if filter.CarID != "" {
+		if _, err := uuid.Parse(filter.CarID); err != nil {
+			return filter, handler.InvalidParam("car_id", "must be a valid UUID")
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxPageSize {
+			return filter, handler.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", models.MaxPageSize))
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
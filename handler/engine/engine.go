@@ -1,15 +1,12 @@
 package engine
 
 import (
-	"encoding/json"
-	"io"
-	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/KRAZYFLASH/carZone/handler"
 	"github.com/KRAZYFLASH/carZone/models"
 	"github.com/KRAZYFLASH/carZone/service"
-	"github.com/google/uuid"
-	"github.com/gorilla/mux"
 	"go.opentelemetry.io/otel"
 )
 
@@ -26,33 +23,66 @@ func (h *EngineHandler) GetEngineById(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "GetEngineById-Handler")
 	defer span.End()
 
-	vars := mux.Vars(r)
-	id := vars["id"]
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
 	resp, err := h.service.GetEngineById(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	if handler.NotModified(w, r, resp.Version) {
+		return
+	}
 
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListEngines serves GET /engine with filters, sort and a keyset cursor.
+func (h *EngineHandler) ListEngines(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("EngineHandler")
+	ctx, span := tracer.Start(r.Context(), "ListEngines-Handler")
+	defer span.End()
+
+	filter, err := parseEngineFilter(r.URL.Query())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error fetching engine by ID:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	body, err := json.Marshal(resp)
+	resp, err := h.service.ListEngines(ctx, filter)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling response:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// EngineUsage serves GET /engine/usage: engines with the number of cars
+// using each. It accepts the same query as ListEngines; cars_max=0 lists
+// the unused engines.
+func (h *EngineHandler) EngineUsage(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("EngineHandler")
+	ctx, span := tracer.Start(r.Context(), "EngineUsage-Handler")
+	defer span.End()
 
-	// Write the response body
-	_, err = w.Write(body)
+	filter, err := parseEngineFilter(r.URL.Query())
 	if err != nil {
-		log.Println("Error writing response:", err)
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.EngineUsage(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
 	}
 
+	handler.WriteJSON(w, http.StatusOK, resp)
 }
 
 func (h *EngineHandler) CreateEngine(w http.ResponseWriter, r *http.Request) {
@@ -60,129 +90,141 @@ func (h *EngineHandler) CreateEngine(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "CreateEngine-Handler")
 	defer span.End()
 
-	body, err := io.ReadAll(r.Body)
+	var engineReq models.EngineRequest
+	if err := handler.DecodeJSON(r, &engineReq); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	createdEngine, err := h.service.CreateEngine(ctx, &engineReq)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error reading request body:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	var engineReq models.EngineRequest
+	handler.WriteJSON(w, http.StatusCreated, createdEngine)
+}
 
-	err = json.Unmarshal(body, &engineReq)
+func (h *EngineHandler) UpdateEngine(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("EngineHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateEngine-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		log.Println("Error unmarshalling request body:", err)
-		return	
+		handler.WriteError(w, err)
+		return
 	}
 
-	createdEngine, err := h.service.CreateEngine(ctx, &engineReq)
+	match, err := handler.IfMatch(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error creating engine:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	responseBody, err := json.Marshal(createdEngine)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling response:", err)
+	var engineReq models.EngineRequest
+	if err := handler.DecodeJSON(r, &engineReq); err != nil {
+		handler.WriteError(w, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	_, err = w.Write(responseBody)
 
+	updatedEngine, err := h.service.UpdateEngine(ctx, id, &engineReq, match)
 	if err != nil {
-		log.Println("Error writing response:", err)
+		handler.WriteError(w, err)
+		return
 	}
 
+	w.Header().Set("ETag", handler.ETag(updatedEngine.Version))
+	handler.WriteJSON(w, http.StatusOK, updatedEngine)
 }
 
-func (h *EngineHandler) UpdateEngine(w http.ResponseWriter, r *http.Request) {
+func (h *EngineHandler) PatchEngine(w http.ResponseWriter, r *http.Request) {
 	tracer := otel.Tracer("EngineHandler")
-	ctx, span := tracer.Start(r.Context(), "UpdateEngine-Handler")
+	ctx, span := tracer.Start(r.Context(), "PatchEngine-Handler")
 	defer span.End()
 
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	body, err := io.ReadAll(r.Body)
+	id, err := handler.PathID(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error reading request body:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	var engineReq models.EngineRequest
-
-	err = json.Unmarshal(body, &engineReq)
+	match, err := handler.IfMatch(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		log.Println("Error unmarshalling request body:", err)
-		return	
+		handler.WriteError(w, err)
+		return
 	}
 
-	updatedEngine, err := h.service.UpdateEngine(ctx, id, &engineReq)
+	patch, err := handler.DecodePatch(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error updating engine:", err)
+		handler.WriteError(w, err)
 		return
-	}	
+	}
 
-	responseBody, err := json.Marshal(updatedEngine)
+	patchedEngine, err := h.service.PatchEngine(ctx, id, patch, match)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling response:", err)
+		handler.WriteError(w, err)
 		return
-	}	
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(responseBody)
-	if err != nil {
-		log.Println("Error writing response:", err)
 	}
+
+	w.Header().Set("ETag", handler.ETag(patchedEngine.Version))
+	handler.WriteJSON(w, http.StatusOK, patchedEngine)
 }
 
+// DeleteEngine serves DELETE /engine/{id}. An engine still used by cars is
+// refused with 409 unless ?cascade=true, which moves those cars to the trash
+// too.
 func (h *EngineHandler) DeleteEngine(w http.ResponseWriter, r *http.Request) {
 	tracer := otel.Tracer("EngineHandler")
 	ctx, span := tracer.Start(r.Context(), "DeleteEngine-Handler")
 	defer span.End()
-	
-	vars := mux.Vars(r)
-	id := vars["id"]
 
-	deletedEngine, err := h.service.DeleteEngine(ctx, id)
+	id, err := handler.PathID(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error deleting engine:", err)
-		response := map[string]string{"error": "Error deleting engine"}
-		responseBody, _ := json.Marshal(response)
-		_, _ = w.Write(responseBody)
+		handler.WriteError(w, err)
 		return
 	}
 
-	if deletedEngine.EngineID == uuid.Nil{
-		w.WriteHeader(http.StatusNotFound)
-		responseBody := map[string]string{"error": "Engine not found"}
-		jsonResponse, _ := json.Marshal(responseBody)
-		_, _ = w.Write(jsonResponse)
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var cascade bool
+	if v := r.URL.Query().Get("cascade"); v != "" {
+		if cascade, err = strconv.ParseBool(v); err != nil {
+			handler.WriteError(w, models.Validation("cascade must be true or false", models.FieldError{Field: "cascade", Message: "must be true or false"}))
+			return
+		}
+	}
+
+	deletedEngine, err := h.service.DeleteEngine(ctx, id, cascade, match)
+	if err != nil {
+		handler.WriteError(w, err)
 		return
 	}
 
-	jsonResponse, err := json.Marshal(deletedEngine)
+	handler.WriteJSON(w, http.StatusOK, deletedEngine)
+}
+
+func (h *EngineHandler) RestoreEngine(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("EngineHandler")
+	ctx, span := tracer.Start(r.Context(), "RestoreEngine-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
 	if err != nil {
-		log.Println("Error marshalling response:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		responseBody := map[string]string{"error": "Error processing response"}
-		jsonResponse, _ := json.Marshal(responseBody)
-		_, _ = w.Write(jsonResponse)
+		handler.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonResponse)
+	restoredEngine, err := h.service.RestoreEngine(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
 
-}
\ No newline at end of file
+	w.Header().Set("ETag", handler.ETag(restoredEngine.Version))
+	handler.WriteJSON(w, http.StatusOK, restoredEngine)
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+// parseEngineFilter reads the engine listing query string, e.g.
+// ?cylinders_min=4&range_max=600&cars_max=0&sort=-cars,displacement&limit=50
+func parseEngineFilter(q url.Values) (models.EngineFilter, error) {
+	filter := models.EngineFilter{Cursor: q.Get("cursor")}
+
+	var err error
+	if filter.DisplacementMin, err = handler.Int64Param(q, "displacement_min"); err != nil {
+		return filter, err
+	}
+	if filter.DisplacementMax, err = handler.Int64Param(q, "displacement_max"); err != nil {
+		return filter, err
+	}
+	if filter.CylindersMin, err = handler.IntParam(q, "cylinders_min"); err != nil {
+		return filter, err
+	}
+	if filter.CylindersMax, err = handler.IntParam(q, "cylinders_max"); err != nil {
+		return filter, err
+	}
+	if filter.RangeMin, err = handler.Int64Param(q, "range_min"); err != nil {
+		return filter, err
+	}
+	if filter.RangeMax, err = handler.Int64Param(q, "range_max"); err != nil {
+		return filter, err
+	}
+	if filter.CarsMin, err = handler.IntParam(q, "cars_min"); err != nil {
+		return filter, err
+	}
+	if filter.CarsMax, err = handler.IntParam(q, "cars_max"); err != nil {
+		return filter, err
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxPageSize {
+			return filter, handler.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", models.MaxPageSize))
+		}
+		filter.Limit = n
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			sf := models.SortField{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(sf.Field, "-") {
+				sf.Field, sf.Desc = sf.Field[1:], true
+			}
+			if !models.IsEngineSortField(sf.Field) {
+				return filter, handler.InvalidParam("sort", fmt.Sprintf("cannot sort by %q; allowed: %s", sf.Field, strings.Join(models.EngineSortFields, ", ")))
+			}
+			filter.Sort = append(filter.Sort, sf)
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+// ETag formats a row version as a strong entity tag.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatch parses the If-Match header into the versions it accepts. A missing
+// header or "*" yields nil, which leaves the write unconditional. Weak tags
+// never match under the strong comparison If-Match requires.
+func IfMatch(r *http.Request) (models.VersionMatch, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return nil, nil
+	}
+
+	match := models.VersionMatch{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		version, ok := parseTag(tag)
+		if !ok {
+			return nil, models.BadRequest("If-Match must be a list of entity tags")
+		}
+		match = append(match, version)
+	}
+	return match, nil
+}
+
+// NotModified reports whether If-None-Match already covers version, using
+// the weak comparison conditional GETs allow. It sets the ETag header either
+// way and writes the 304 when it returns true.
+func NotModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	w.Header().Set("ETag", ETag(version))
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if v, ok := parseTag(tag); tag == "*" || (ok && v == version) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+func parseTag(tag string) (int, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	return version, err == nil
+}
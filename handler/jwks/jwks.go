@@ -0,0 +1,27 @@
+package jwks
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/keyset"
+)
+
+type JWKSHandler struct {
+	keys *keyset.KeySet
+}
+
+func NewJWKSHandler(keys *keyset.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS serves the public verification keys so other services can check
+// CarZone tokens without sharing a secret.
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(h.keys.JWKS()); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
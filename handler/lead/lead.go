@@ -0,0 +1,257 @@
+package lead
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type LeadHandler struct {
+	service service.LeadServiceInterface
+}
+
+func NewLeadHandler(service service.LeadServiceInterface) *LeadHandler {
+	return &LeadHandler{service: service}
+}
+
+func (h *LeadHandler) GetLead(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LeadHandler")
+	ctx, span := tracer.Start(r.Context(), "GetLead-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.GetLead(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	if handler.NotModified(w, r, resp.Version) {
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListLeads serves GET /leads, newest first. assigned_to=me lists the
+// caller's own leads and unassigned=true the leads nobody has taken.
+func (h *LeadHandler) ListLeads(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LeadHandler")
+	ctx, span := tracer.Start(r.Context(), "ListLeads-Handler")
+	defer span.End()
+
+	filter, err := parseLeadFilter(r.URL.Query())
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	if filter.AssignedTo == "me" {
+		username, ok := middleware.UsernameFromContext(ctx)
+		if !ok {
+			handler.WriteError(w, models.Unauthorized("Unauthorized"))
+			return
+		}
+		filter.AssignedTo = username
+	}
+
+	resp, err := h.service.ListLeads(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *LeadHandler) CreateLead(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LeadHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateLead-Handler")
+	defer span.End()
+
+	var req models.LeadRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	created, err := h.service.CreateLead(ctx, &req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(created.Version))
+	handler.WriteJSON(w, http.StatusCreated, created)
+}
+
+func (h *LeadHandler) UpdateLead(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LeadHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateLead-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.LeadRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	updated, err := h.service.UpdateLead(ctx, id, &req, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(updated.Version))
+	handler.WriteJSON(w, http.StatusOK, updated)
+}
+
+// AssignLead serves POST /leads/{id}/assign, assigning the lead to the
+// salesperson named in the caller's token.
+func (h *LeadHandler) AssignLead(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LeadHandler")
+	ctx, span := tracer.Start(r.Context(), "AssignLead-Handler")
+	defer span.End()
+
+	username, ok := middleware.UsernameFromContext(ctx)
+	if !ok {
+		handler.WriteError(w, models.Unauthorized("Unauthorized"))
+		return
+	}
+	h.assign(ctx, w, r, username)
+}
+
+// UnassignLead serves POST /leads/{id}/unassign.
+func (h *LeadHandler) UnassignLead(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LeadHandler")
+	ctx, span := tracer.Start(r.Context(), "UnassignLead-Handler")
+	defer span.End()
+
+	h.assign(ctx, w, r, "")
+}
+
+func (h *LeadHandler) assign(ctx context.Context, w http.ResponseWriter, r *http.Request, username string) {
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	lead, err := h.service.AssignLead(ctx, id, username, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(lead.Version))
+	handler.WriteJSON(w, http.StatusOK, lead)
+}
+
+func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LeadHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteLead-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	deleted, err := h.service.DeleteLead(ctx, id, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, deleted)
+}
+
+func parseLeadFilter(q url.Values) (models.LeadFilter, error) {
+	filter := models.LeadFilter{
+		Status:     q.Get("status"),
+		AssignedTo: q.Get("assigned_to"),
+		CustomerID: q.Get("customer_id"),
+		CarID:      q.Get("car_id"),
+		Cursor:     q.Get("cursor"),
+	}
+
+	if filter.Status != "" {
+		valid := false
+		for _, s := range models.LeadStatuses {
+			valid = valid || s == filter.Status
+		}
+		if !valid {
+			return filter, handler.InvalidParam("status", "must be one of the following: "+strings.Join(models.LeadStatuses, ", "))
+		}
+	}
+
+	if unassigned := q.Get("unassigned"); unassigned != "" {
+		b, err := strconv.ParseBool(unassigned)
+		if err != nil {
+			return filter, handler.InvalidParam("unassigned", "must be true or false")
+		}
+		filter.Unassigned = b
+	}
+	if filter.Unassigned && filter.AssignedTo != "" {
+		return filter, handler.InvalidParam("unassigned", "cannot be combined with assigned_to")
+	}
+
+	if filter.CustomerID != "" {
+		if _, err := uuid.Parse(filter.CustomerID); err != nil {
+			return filter, handler.InvalidParam("customer_id", "must be a valid UUID")
+		}
+	}
+	if filter.CarID != "" {
+		if _, err := uuid.Parse(filter.CarID); err != nil {
+			return filter, handler.InvalidParam("car_id", "must be a valid UUID")
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxPageSize {
+			return filter, handler.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", models.MaxPageSize))
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
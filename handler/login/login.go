@@ -1,58 +1,83 @@
 package login
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
-	"time"
 
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/middleware"
 	"github.com/KRAZYFLASH/carZone/models"
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/KRAZYFLASH/carZone/service"
+	"go.opentelemetry.io/otel"
 )
 
+type LoginHandler struct {
+	service service.AuthServiceInterface
+}
+
+func NewLoginHandler(service service.AuthServiceInterface) *LoginHandler {
+	return &LoginHandler{service: service}
+}
 
+func (h *LoginHandler) Login(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LoginHandler")
+	ctx, span := tracer.Start(r.Context(), "Login-Handler")
+	defer span.End()
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var credentials models.Credential
-	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
-		http.Error(w, "Invalid Request Body", http.StatusBadRequest)
+	if err := handler.DecodeJSON(r, &credentials); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	tokens, err := h.service.Login(ctx, credentials)
+	if err != nil {
+		handler.WriteError(w, err)
 		return
 	}
 
-	valid := (credentials.Username == "admin" && credentials.Password == "admin123")
+	writeTokens(w, tokens)
+}
+
+func (h *LoginHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LoginHandler")
+	ctx, span := tracer.Start(r.Context(), "Refresh-Handler")
+	defer span.End()
 
-	if !valid {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	var req models.RefreshRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
 		return
 	}
 
-	tokenString, err := GenerateToken(credentials.Username)
+	tokens, err := h.service.Refresh(ctx, req.RefreshToken)
 	if err != nil {
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
-		log.Println("Error generating token:", err)
+		handler.WriteError(w, err)
 		return
 	}
 
-	response := map[string]string{"token": tokenString}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeTokens(w, tokens)
 }
 
+func (h *LoginHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LoginHandler")
+	ctx, span := tracer.Start(r.Context(), "Logout-Handler")
+	defer span.End()
 
-func GenerateToken(username string) (string, error) {
-	expiration := time.Now().Add(24 * time.Hour)
-
-	claims := &jwt.RegisteredClaims{
-		Subject:   username,
-		ExpiresAt: jwt.NewNumericDate(expiration),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		handler.WriteError(w, models.Unauthorized("Unauthorized"))
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte("some_value")) // TODO: ganti ke ENV:
-	if err != nil {
-		return "", err
+	if err := h.service.Logout(ctx, claims.ID, claims.SessionID, claims.ExpiresAt.Time); err != nil {
+		handler.WriteError(w, err)
+		return
 	}
 
-	return signedToken, nil
-}
\ No newline at end of file
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTokens(w http.ResponseWriter, tokens *models.TokenPair) {
+	w.Header().Set("Cache-Control", "no-store")
+	handler.WriteJSON(w, http.StatusOK, tokens)
+}
@@ -0,0 +1,300 @@
+package order
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/invoice"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type OrderHandler struct {
+	service service.OrderServiceInterface
+}
+
+func NewOrderHandler(service service.OrderServiceInterface) *OrderHandler {
+	return &OrderHandler{service: service}
+}
+
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "GetOrder-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.GetOrder(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	if handler.NotModified(w, r, resp.Version) {
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListOrders serves GET /orders?status=&payment_status=&car_id=, newest
+// first.
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "ListOrders-Handler")
+	defer span.End()
+
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.ListOrders(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateOrder-Handler")
+	defer span.End()
+
+	var req models.OrderRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	created, err := h.service.CreateOrder(ctx, &req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(created.Version))
+	handler.WriteJSON(w, http.StatusCreated, created)
+}
+
+func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateOrder-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.OrderRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	updated, err := h.service.UpdateOrder(ctx, id, &req, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(updated.Version))
+	handler.WriteJSON(w, http.StatusOK, updated)
+}
+
+// SetPaymentStatus serves PUT /orders/{id}/payment.
+func (h *OrderHandler) SetPaymentStatus(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "SetPaymentStatus-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.PaymentRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	updated, err := h.service.SetPaymentStatus(ctx, id, &req, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(updated.Version))
+	handler.WriteJSON(w, http.StatusOK, updated)
+}
+
+// CompleteOrder serves POST /orders/{id}/complete, which also marks the car
+// sold.
+func (h *OrderHandler) CompleteOrder(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "CompleteOrder-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	completed, err := h.service.CompleteOrder(ctx, id, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(completed.Version))
+	handler.WriteJSON(w, http.StatusOK, completed)
+}
+
+// CancelOrder serves DELETE /orders/{id}. Orders are cancelled rather than
+// removed, and only while open.
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "CancelOrder-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	cancelled, err := h.service.CancelOrder(ctx, id, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, cancelled)
+}
+
+// GetInvoice serves GET /orders/{id}/invoice?format=json|pdf.
+func (h *OrderHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OrderHandler")
+	ctx, span := tracer.Start(r.Context(), "GetInvoice-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "pdf" {
+		handler.WriteError(w, handler.InvalidParam("format", "must be one of the following: json, pdf"))
+		return
+	}
+
+	inv, err := h.service.GetInvoice(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	if format == "json" {
+		handler.WriteJSON(w, http.StatusOK, inv)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := invoice.WritePDF(&buf, *inv); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", invoice.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, inv.Number))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Println("Error writing invoice:", err)
+	}
+}
+
+func parseOrderFilter(q url.Values) (models.OrderFilter, error) {
+	filter := models.OrderFilter{
+		Status:        q.Get("status"),
+		PaymentStatus: q.Get("payment_status"),
+		CarID:         q.Get("car_id"),
+		Cursor:        q.Get("cursor"),
+	}
+
+	if filter.Status != "" && !contains(models.OrderStatuses, filter.Status) {
+		return filter, handler.InvalidParam("status", "must be one of the following: "+strings.Join(models.OrderStatuses, ", "))
+	}
+	if filter.PaymentStatus != "" && !contains(models.PaymentStatuses, filter.PaymentStatus) {
+		return filter, handler.InvalidParam("payment_status", "must be one of the following: "+strings.Join(models.PaymentStatuses, ", "))
+	}
+	if filter.CarID != "" {
+		if _, err := uuid.Parse(filter.CarID); err != nil {
+			return filter, handler.InvalidParam("car_id", "must be a valid UUID")
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxPageSize {
+			return filter, handler.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", models.MaxPageSize))
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
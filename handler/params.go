@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+// InvalidParam reports a bad query parameter as a validation error on that
+// parameter.
+func InvalidParam(name, message string) error {
+	return models.Validation(name+" "+message, models.FieldError{Field: name, Message: message})
+}
+
+// IntParam reads an optional integer query parameter; nil means absent.
+func IntParam(q url.Values, name string) (*int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, InvalidParam(name, "must be an integer")
+	}
+	return &n, nil
+}
+
+// Int64Param reads an optional 64-bit integer query parameter.
+func Int64Param(q url.Values, name string) (*int64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, InvalidParam(name, "must be an integer")
+	}
+	return &n, nil
+}
+
+// FloatParam reads an optional numeric query parameter.
+func FloatParam(q url.Values, name string) (*float64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, InvalidParam(name, "must be a number")
+	}
+	return &n, nil
+}
@@ -0,0 +1,108 @@
+package reservation
+
+import (
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"go.opentelemetry.io/otel"
+)
+
+type ReservationHandler struct {
+	service service.ReservationServiceInterface
+}
+
+func NewReservationHandler(service service.ReservationServiceInterface) *ReservationHandler {
+	return &ReservationHandler{service: service}
+}
+
+func (h *ReservationHandler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ReservationHandler")
+	ctx, span := tracer.Start(r.Context(), "GetReservation-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.GetReservation(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListReservations serves GET /cars/{id}/reservations, newest first.
+func (h *ReservationHandler) ListReservations(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ReservationHandler")
+	ctx, span := tracer.Start(r.Context(), "ListReservations-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	reservations, err := h.service.ListReservations(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, map[string]any{"reservations": reservations})
+}
+
+// CreateReservation serves POST /cars/{id}/reservations. hold_hours defaults
+// to 48.
+func (h *ReservationHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ReservationHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateReservation-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.ReservationRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.CreateReservation(ctx, id, &req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusCreated, resp)
+}
+
+// ReleaseReservation serves POST /reservations/{id}/release.
+func (h *ReservationHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ReservationHandler")
+	ctx, span := tracer.Start(r.Context(), "ReleaseReservation-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.ReleaseReservation(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/jsonpatch"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader is set on every response by middleware.RequestID.
+const RequestIDHeader = "X-Request-ID"
+
+type ErrorBody struct {
+	Code      string              `json:"code"`
+	Message   string              `json:"message"`
+	Fields    []models.FieldError `json:"fields,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+var errorStatuses = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{models.ErrNotFound, http.StatusNotFound, "not_found"},
+	{models.ErrValidation, http.StatusUnprocessableEntity, "validation_failed"},
+	{models.ErrBadRequest, http.StatusBadRequest, "bad_request"},
+	{models.ErrConflict, http.StatusConflict, "conflict"},
+	{models.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{models.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{models.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+	{models.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+}
+
+// WriteError maps err to a status code and writes the JSON error body.
+// Errors that are not typed domain errors are logged and reported as a
+// generic 500 so internal details do not leak to clients.
+func WriteError(w http.ResponseWriter, err error) {
+	body := ErrorBody{
+		Code:      "internal_error",
+		Message:   "internal server error",
+		RequestID: w.Header().Get(RequestIDHeader),
+	}
+	status := http.StatusInternalServerError
+
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.kind) {
+			status, body.Code, body.Message = e.status, e.code, err.Error()
+			var typed *models.Error
+			if errors.As(err, &typed) {
+				body.Fields = typed.Fields
+			}
+			break
+		}
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("request %s: %v", body.RequestID, err)
+	}
+
+	WriteJSON(w, status, body)
+}
+
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Println("Error marshalling response:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// DecodeJSON decodes the request body into v, reporting malformed JSON as a
+// bad request.
+func DecodeJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return models.BadRequest("Invalid JSON body: " + err.Error())
+	}
+	return nil
+}
+
+// DecodePatch reads a merge patch or JSON Patch body, chosen by the request
+// Content-Type.
+func DecodePatch(r *http.Request) (jsonpatch.Patch, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, models.BadRequest("Failed to read request body")
+	}
+	patch, err := jsonpatch.Decode(r.Header.Get("Content-Type"), body)
+	if errors.Is(err, jsonpatch.ErrUnsupportedContentType) {
+		return nil, models.UnsupportedMediaType(err.Error())
+	}
+	if err != nil {
+		return nil, models.BadRequest(err.Error())
+	}
+	return patch, nil
+}
+
+// PathID returns the {id} route variable after checking it is a UUID.
+func PathID(r *http.Request) (string, error) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		return "", models.Validation("id must be a valid UUID", models.FieldError{Field: "id", Message: "must be a valid UUID"})
+	}
+	return id, nil
+}
@@ -0,0 +1,263 @@
+package testdrive
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/calendar"
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+// feedHistory is how far back the calendar feed reaches, so recent drives
+// stay visible in subscribed calendars.
+const feedHistory = 30 * 24 * time.Hour
+
+type TestDriveHandler struct {
+	service service.TestDriveServiceInterface
+}
+
+func NewTestDriveHandler(service service.TestDriveServiceInterface) *TestDriveHandler {
+	return &TestDriveHandler{service: service}
+}
+
+func (h *TestDriveHandler) GetTestDrive(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TestDriveHandler")
+	ctx, span := tracer.Start(r.Context(), "GetTestDrive-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.service.GetTestDrive(ctx, id)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	if handler.NotModified(w, r, resp.Version) {
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListTestDrives serves GET /cars/{id}/test-drives?from=&to=&status=, the
+// car's schedule earliest first. from defaults to now.
+func (h *TestDriveHandler) ListTestDrives(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TestDriveHandler")
+	ctx, span := tracer.Start(r.Context(), "ListTestDrives-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	filter, err := parseTestDriveFilter(r.URL.Query(), time.Now())
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	filter.CarID = id
+
+	drives, err := h.service.ListTestDrives(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusOK, map[string]any{"test_drives": drives})
+}
+
+// CreateTestDrive serves POST /cars/{id}/test-drives. salesperson defaults
+// to the caller.
+func (h *TestDriveHandler) CreateTestDrive(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TestDriveHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateTestDrive-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.TestDriveRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	created, err := h.service.CreateTestDrive(ctx, id, &req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(created.Version))
+	handler.WriteJSON(w, http.StatusCreated, created)
+}
+
+// RescheduleTestDrive serves POST /test-drives/{id}/reschedule.
+func (h *TestDriveHandler) RescheduleTestDrive(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TestDriveHandler")
+	ctx, span := tracer.Start(r.Context(), "RescheduleTestDrive-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var req models.RescheduleRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	updated, err := h.service.RescheduleTestDrive(ctx, id, &req, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(updated.Version))
+	handler.WriteJSON(w, http.StatusOK, updated)
+}
+
+// CancelTestDrive serves POST /test-drives/{id}/cancel.
+func (h *TestDriveHandler) CancelTestDrive(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TestDriveHandler")
+	ctx, span := tracer.Start(r.Context(), "CancelTestDrive-Handler")
+	defer span.End()
+
+	id, err := handler.PathID(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	match, err := handler.IfMatch(r)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	cancelled, err := h.service.CancelTestDrive(ctx, id, match)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", handler.ETag(cancelled.Version))
+	handler.WriteJSON(w, http.StatusOK, cancelled)
+}
+
+// SalespersonCalendar serves GET /salespeople/{username}/test-drives.ics, an
+// iCalendar feed of the salesperson's test drives from the last 30 days
+// onwards, cancelled ones included. "me" stands for the caller. The feed
+// carries customer contacts, so only the salesperson and users with
+// PermSalesManage may read it.
+func (h *TestDriveHandler) SalespersonCalendar(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TestDriveHandler")
+	ctx, span := tracer.Start(r.Context(), "SalespersonCalendar-Handler")
+	defer span.End()
+
+	caller, ok := middleware.UsernameFromContext(ctx)
+	if !ok {
+		handler.WriteError(w, models.Unauthorized("Unauthorized"))
+		return
+	}
+	username := mux.Vars(r)["username"]
+	if username == "me" {
+		username = caller
+	}
+	if username != caller && !middleware.RoleFromContext(ctx).Can(models.PermSalesManage) {
+		handler.WriteError(w, models.Forbidden("missing permission "+string(models.PermSalesManage)))
+		return
+	}
+
+	filter, err := parseTestDriveFilter(r.URL.Query(), time.Now().Add(-feedHistory))
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	filter.Salesperson = username
+
+	drives, err := h.service.ListTestDrives(ctx, filter)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.WriteTestDrives(&buf, "Test drives: "+username, drives); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", calendar.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="test-drives-%s.ics"`, username))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Println("Error writing calendar:", err)
+	}
+}
+
+// parseTestDriveFilter reads the from, to and status parameters. from
+// defaults to defaultFrom.
+func parseTestDriveFilter(q url.Values, defaultFrom time.Time) (models.TestDriveFilter, error) {
+	filter := models.TestDriveFilter{
+		Status: q.Get("status"),
+		From:   defaultFrom,
+	}
+
+	if filter.Status != "" {
+		valid := false
+		for _, s := range models.TestDriveStatuses {
+			valid = valid || s == filter.Status
+		}
+		if !valid {
+			return filter, handler.InvalidParam("status", "must be one of the following: "+strings.Join(models.TestDriveStatuses, ", "))
+		}
+	}
+
+	if from := q.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, handler.InvalidParam("from", "must be an RFC 3339 timestamp")
+		}
+		filter.From = t
+	}
+	if to := q.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, handler.InvalidParam("to", "must be an RFC 3339 timestamp")
+		}
+		if !t.After(filter.From) {
+			return filter, handler.InvalidParam("to", "must be after from")
+		}
+		filter.To = t
+	}
+
+	return filter, nil
+}
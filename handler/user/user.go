@@ -0,0 +1,101 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type UserHandler struct {
+	service service.UserServiceInterface
+}
+
+func NewUserHandler(service service.UserServiceInterface) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("UserHandler")
+	ctx, span := tracer.Start(r.Context(), "Register-Handler")
+	defer span.End()
+
+	var req models.RegisterRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	user, err := h.service.Register(ctx, &req)
+	if err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	handler.WriteJSON(w, http.StatusCreated, user)
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("UserHandler")
+	ctx, span := tracer.Start(r.Context(), "ChangePassword-Handler")
+	defer span.End()
+
+	username, ok := middleware.UsernameFromContext(ctx)
+	if !ok {
+		handler.WriteError(w, models.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	if err := h.service.ChangePassword(ctx, username, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("UserHandler")
+	ctx, span := tracer.Start(r.Context(), "Deactivate-Handler")
+	defer span.End()
+
+	username := mux.Vars(r)["username"]
+
+	if err := h.service.Deactivate(ctx, username); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("UserHandler")
+	ctx, span := tracer.Start(r.Context(), "ChangeRole-Handler")
+	defer span.End()
+
+	username := mux.Vars(r)["username"]
+
+	var req models.ChangeRoleRequest
+	if err := handler.DecodeJSON(r, &req); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	if err := h.service.ChangeRole(ctx, username, req.Role); err != nil {
+		handler.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,34 @@
+package validation
+
+import (
+	"net/http"
+
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+type ValidationHandler struct{}
+
+func NewValidationHandler() *ValidationHandler {
+	return &ValidationHandler{}
+}
+
+// GetRules describes the validation rules of every request body so the UI
+// can validate forms with the same constraints as the server.
+func (h *ValidationHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	rules := map[string][]models.RuleDescription{
+		"car":            models.CarRequestRules().Describe(),
+		"engine":         models.EngineRequestRules().Describe(),
+		"reservation":    models.ReservationRequestRules().Describe(),
+		"order":          models.OrderRequestRules().Describe(),
+		"payment":        models.PaymentRequestRules().Describe(),
+		"customer":       models.CustomerRequestRules().Describe(),
+		"customerNote":   models.CustomerNoteRequestRules().Describe(),
+		"lead":           models.LeadRequestRules().Describe(),
+		"testDrive":      models.TestDriveRequestRules().Describe(),
+		"reschedule":     models.RescheduleRequestRules().Describe(),
+		"register":       models.RegisterRequestRules().Describe(),
+		"changePassword": models.ChangePasswordRequestRules().Describe(),
+	}
+	handler.WriteJSON(w, http.StatusOK, rules)
+}
@@ -0,0 +1,234 @@
+// Package invoice renders order invoices as single-page PDF documents. It
+// writes PDF 1.4 by hand with the standard Helvetica fonts, which every
+// reader provides, so no font files or third-party libraries are needed.
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+const ContentType = "application/pdf"
+
+// A4 in points, with the margins used for every line.
+const (
+	pageWidth   = 595
+	pageHeight  = 842
+	marginLeft  = 50
+	marginRight = pageWidth - 50
+)
+
+// maxLineChars truncates long descriptions so they do not run into the
+// amount column.
+const maxLineChars = 70
+
+// WritePDF renders inv as a PDF document to w.
+func WritePDF(w io.Writer, inv models.Invoice) error {
+	var p page
+	y := float64(pageHeight - 60)
+
+	p.text(fontBold, 20, marginLeft, y, "INVOICE")
+	p.textRight(fontBold, 12, marginRight, y, "CarZone")
+	y -= 30
+	p.text(fontRegular, 10, marginLeft, y, "Invoice number: "+inv.Number)
+	p.textRight(fontRegular, 10, marginRight, y, "Date: "+inv.IssuedAt.Format("2006-01-02"))
+	y -= 14
+	p.text(fontRegular, 10, marginLeft, y, "Order: "+inv.OrderID.String())
+	y -= 14
+	if inv.Salesperson != "" {
+		p.text(fontRegular, 10, marginLeft, y, "Salesperson: "+inv.Salesperson)
+		y -= 14
+	}
+
+	y -= 16
+	p.text(fontBold, 11, marginLeft, y, "Bill to")
+	y -= 14
+	for _, s := range []string{inv.Customer.Name, inv.Customer.Email, inv.Customer.Phone, inv.Customer.Address} {
+		if s == "" {
+			continue
+		}
+		p.text(fontRegular, 10, marginLeft, y, truncate(s))
+		y -= 14
+	}
+
+	y -= 16
+	p.text(fontBold, 11, marginLeft, y, "Vehicle")
+	y -= 14
+	p.text(fontRegular, 10, marginLeft, y, truncate(fmt.Sprintf("%s %s (%s), %s", inv.Vehicle.Brand, inv.Vehicle.Name, inv.Vehicle.Year, inv.Vehicle.FuelType)))
+	y -= 14
+	p.text(fontRegular, 10, marginLeft, y, "ID: "+inv.Vehicle.ID.String())
+	y -= 30
+
+	p.text(fontBold, 10, marginLeft, y, "Description")
+	p.textRight(fontBold, 10, marginRight, y, "Amount")
+	y -= 6
+	p.rule(y)
+	y -= 14
+	for _, l := range inv.Lines {
+		p.text(fontRegular, 10, marginLeft, y, truncate(l.Description))
+		p.textRight(fontRegular, 10, marginRight, y, Money(l.Amount))
+		y -= 14
+	}
+	p.rule(y + 8)
+	y -= 8
+
+	totals := []struct {
+		label  string
+		amount float64
+	}{
+		{"Subtotal", inv.Totals.Subtotal},
+		{"Discounts", -inv.Totals.Discounts},
+		{"Taxable amount", inv.Totals.Taxable},
+		{"Taxes", inv.Totals.Taxes},
+		{"Trade-in credit", -inv.Totals.TradeInCredit},
+	}
+	for _, t := range totals {
+		p.text(fontRegular, 10, marginRight-200, y, t.label)
+		p.textRight(fontRegular, 10, marginRight, y, Money(t.amount))
+		y -= 14
+	}
+	y -= 4
+	p.text(fontBold, 12, marginRight-200, y, "Total")
+	p.textRight(fontBold, 12, marginRight, y, Money(inv.Totals.Total))
+	y -= 30
+	p.text(fontRegular, 10, marginLeft, y, "Payment status: "+strings.ReplaceAll(inv.PaymentStatus, "_", " "))
+
+	return writeDocument(w, p.buf.Bytes())
+}
+
+// Money formats an amount with thousands separators and two decimals, e.g.
+// "-1,250,000.00".
+func Money(v float64) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', 2, 64)
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+
+	var b strings.Builder
+	if v < 0 && s != "0.00" {
+		b.WriteByte('-')
+	}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(cents)
+	return b.String()
+}
+
+func truncate(s string) string {
+	r := []rune(s)
+	if len(r) <= maxLineChars {
+		return s
+	}
+	return string(r[:maxLineChars-3]) + "..."
+}
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// page collects the drawing operators of the page's content stream.
+type page struct {
+	buf bytes.Buffer
+}
+
+func (p *page) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(&p.buf, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, num(size), num(x), num(y), escape(s))
+}
+
+func (p *page) textRight(font string, size, right, y float64, s string) {
+	p.text(font, size, right-textWidth(s, size), y, s)
+}
+
+// rule draws a thin horizontal line across the page at y.
+func (p *page) rule(y float64) {
+	fmt.Fprintf(&p.buf, "0.5 w %d %s m %d %s l S\n", marginLeft, num(y), marginRight, num(y))
+}
+
+// glyphWidths are Helvetica advance widths in 1/1000 em for the characters
+// that appear in right-aligned text; other characters use avgGlyphWidth.
+// Helvetica-Bold has the same widths for digits and punctuation.
+var glyphWidths = map[rune]float64{
+	'0': 556, '1': 556, '2': 556, '3': 556, '4': 556, '5': 556, '6': 556, '7': 556, '8': 556, '9': 556,
+	',': 278, '.': 278, '-': 333, ' ': 278, ':': 278,
+}
+
+const avgGlyphWidth = 556
+
+func textWidth(s string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		if gw, ok := glyphWidths[r]; ok {
+			w += gw
+		} else {
+			w += avgGlyphWidth
+		}
+	}
+	return w * size / 1000
+}
+
+// escape encodes s as the body of a PDF literal string in WinAnsiEncoding.
+// Latin-1 characters map to themselves; anything else prints as '?'.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// writeDocument wraps one page's content stream in the catalog, page tree,
+// fonts and cross-reference table of a PDF file.
+func writeDocument(w io.Writer, content []byte) error {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 4 0 R /%s 5 0 R >> >> /Contents 6 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
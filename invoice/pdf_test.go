@@ -0,0 +1,176 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+)
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0.00"},
+		{5, "5.00"},
+		{999.999, "1,000.00"},
+		{1234.5, "1,234.50"},
+		{100000, "100,000.00"},
+		{1250000, "1,250,000.00"},
+		{-1250000, "-1,250,000.00"},
+		{-0.001, "0.00"},
+	}
+	for _, tt := range tests {
+		if got := Money(tt.in); got != tt.want {
+			t.Errorf("Money(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a (b) \c`, `a \(b\) \\c`},
+		{"tab\tand\nnewline", "tab and newline"},
+		{"café", `caf\351`},
+		{"€100", "?100"},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	short := strings.Repeat("é", maxLineChars)
+	if got := truncate(short); got != short {
+		t.Errorf("truncated a line of %d characters", maxLineChars)
+	}
+	long := strings.Repeat("é", maxLineChars+1)
+	got := truncate(long)
+	if n := len([]rune(got)); n != maxLineChars || !strings.HasSuffix(got, "...") {
+		t.Errorf("truncate gave %d characters: %q", n, got)
+	}
+}
+
+func testInvoice() models.Invoice {
+	return models.Invoice{
+		Number:      "INV-2024-000042",
+		OrderID:     uuid.MustParse("55555555-5555-5555-5555-555555555555"),
+		IssuedAt:    time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+		Salesperson: "sari",
+		Customer:    models.OrderCustomer{Name: "Budi (Jr.)", Email: "budi@example.com"},
+		Vehicle: models.InvoiceVehicle{
+			ID:       uuid.MustParse("66666666-6666-6666-6666-666666666666"),
+			Brand:    "Toyota",
+			Name:     "Yaris",
+			Year:     "2022",
+			FuelType: "Petrol",
+		},
+		Lines: []models.InvoiceLine{
+			{Description: "Toyota Yaris 2022", Amount: 250000000},
+			{Description: "Loyalty discount", Amount: -5000000},
+		},
+		Totals: models.OrderTotals{
+			Subtotal:  250000000,
+			Discounts: 5000000,
+			Taxable:   245000000,
+			Taxes:     26950000,
+			Total:     271950000,
+		},
+		PaymentStatus: "partially_paid",
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, testInvoice()); err != nil {
+		t.Fatalf("WritePDF: %v", err)
+	}
+	pdf := buf.Bytes()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Errorf("missing PDF header: %q", pdf[:16])
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Errorf("missing %%%%EOF trailer")
+	}
+
+	// startxref points at the cross-reference table.
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n0 7\n0000000000 65535 f \n")) {
+		t.Fatalf("startxref %d does not point at the xref table: %q", xref, pdf[xref:xref+20])
+	}
+
+	// Every xref entry is a 20-octet line giving the offset of its object.
+	entries := pdf[xref+len("xref\n0 7\n"):]
+	for i := 1; i <= 6; i++ {
+		entry := string(entries[i*20 : (i+1)*20])
+		if !strings.HasSuffix(entry, " 00000 n \n") {
+			t.Fatalf("xref entry %d is malformed: %q", i, entry)
+		}
+		off, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", i); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i, pdf[off:off+len(want)], want)
+		}
+	}
+	if !bytes.Contains(pdf, []byte("trailer\n<< /Size 7 /Root 1 0 R >>")) {
+		t.Errorf("missing trailer dictionary")
+	}
+
+	// /Length is the exact size of the content stream.
+	m = regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*)\nendstream`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatalf("missing content stream")
+	}
+	if length, _ := strconv.Atoi(string(m[1])); length != len(m[2]) {
+		t.Errorf("/Length %d, stream is %d octets", length, len(m[2]))
+	}
+
+	content := string(m[2])
+	for _, want := range []string{
+		"(INVOICE) Tj",
+		"(Invoice number: INV-2024-000042) Tj",
+		"(Date: 2024-06-01) Tj",
+		`(Budi \(Jr.\)) Tj`,
+		"(Toyota Yaris \\(2022\\), Petrol) Tj",
+		"(250,000,000.00) Tj",
+		"(-5,000,000.00) Tj",
+		"(271,950,000.00) Tj",
+		"(Payment status: partially paid) Tj",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content stream is missing %q", want)
+		}
+	}
+}
+
+// Right-aligned amounts end at the right margin whatever their width.
+func TestTextRight(t *testing.T) {
+	for _, s := range []string{"0.00", "1,250,000.00", "-5,000.00"} {
+		var p page
+		p.textRight(fontRegular, 10, marginRight, 100, s)
+		m := regexp.MustCompile(`Tf ([\d.]+) 100 Td`).FindStringSubmatch(p.buf.String())
+		if m == nil {
+			t.Fatalf("unexpected operators %q", p.buf.String())
+		}
+		x, _ := strconv.ParseFloat(m[1], 64)
+		if end := x + textWidth(s, 10); end < marginRight-0.001 || end > marginRight+0.001 {
+			t.Errorf("%q ends at %v, want %v", s, end, marginRight)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual compares two JSON documents ignoring member order and spacing.
+func jsonEqual(t *testing.T, got, want string) bool {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("result is not JSON: %v: %s", err, got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("expected value is not JSON: %v: %s", err, want)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func apply(t *testing.T, contentType, doc, patch string) (string, error) {
+	t.Helper()
+	p, err := Decode(contentType, []byte(patch))
+	if err != nil {
+		return "", err
+	}
+	out, err := p.Apply([]byte(doc))
+	return string(out), err
+}
+
+// The examples of RFC 6902 Appendix A, plus the cases around pointer
+// escaping, "-" and moving a value into its own child.
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "A.1 adding an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name:  "A.2 adding an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name:  "A.3 removing an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			name:  "A.4 removing an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:  "A.5 replacing a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name:  "A.6 moving a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name:  "A.7 moving an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name: "A.8 testing a value: success",
+			doc:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"},
+			         {"op": "test", "path": "/foo/1", "value": 2}]`,
+			want: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:    "A.9 testing a value: error",
+			doc:     `{"baz": "qux"}`,
+			patch:   `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.10 adding a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name:  "A.11 ignoring unrecognized elements",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want:  `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name:    "A.12 adding to a nonexistent target",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "A.14 ~ escape ordering",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10}]`,
+			want:  `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:    "A.15 comparing strings and numbers",
+			doc:     `{"/": 9, "~1": 10}`,
+			patch:   `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.16 adding an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		{
+			name:  "pointer with an escaped slash",
+			doc:   `{"a/b": 1}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 2}]`,
+			want:  `{"a/b": 2}`,
+		},
+		{
+			name:  "pointer with an escaped tilde",
+			doc:   `{"m~n": 1}`,
+			patch: `[{"op": "remove", "path": "/m~0n"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "add to the end of a nested array",
+			doc:   `{"a": {"list": [1, 2]}}`,
+			patch: `[{"op": "add", "path": "/a/list/-", "value": 3}]`,
+			want:  `{"a": {"list": [1, 2, 3]}}`,
+		},
+		{
+			name:    "- is not an index for remove",
+			doc:     `{"foo": [1, 2]}`,
+			patch:   `[{"op": "remove", "path": "/foo/-"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "array index with a leading zero",
+			doc:     `{"foo": [1, 2]}`,
+			patch:   `[{"op": "replace", "path": "/foo/01", "value": 3}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "move into its own child",
+			doc:     `{"a": {"b": {}}}`,
+			patch:   `[{"op": "move", "from": "/a", "path": "/a/b/c"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "move to a sibling with a shared prefix",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/ab"}]`,
+			want:  `{"ab": 1}`,
+		},
+		{
+			name:  "copy does not alias the source",
+			doc:   `{"a": {"x": 1}}`,
+			patch: `[{"op": "copy", "from": "/a", "path": "/b"}, {"op": "replace", "path": "/b/x", "value": 2}]`,
+			want:  `{"a": {"x": 1}, "b": {"x": 2}}`,
+		},
+		{
+			name:  "test compares objects regardless of member order",
+			doc:   `{"obj": {"a": 1, "b": [true, null]}}`,
+			patch: `[{"op": "test", "path": "/obj", "value": {"b": [true, null], "a": 1.0}}]`,
+			want:  `{"obj": {"a": 1, "b": [true, null]}}`,
+		},
+		{
+			name:    "test compares arrays in order",
+			doc:     `{"list": [1, 2]}`,
+			patch:   `[{"op": "test", "path": "/list", "value": [2, 1]}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:    "a failing operation rejects the whole patch",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "replace", "path": "/a", "value": 2}, {"op": "remove", "path": "/missing"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "pointer without a leading slash",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "remove", "path": "a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "increment", "path": "/a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "add without a value",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "add", "path": "/b"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := apply(t, JSONPatchContentType, tt.doc, tt.patch)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The examples of RFC 7396 Appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a": "b"}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "b"}`, `{"b": "c"}`, `{"a": "b", "b": "c"}`},
+		{`{"a": "b"}`, `{"a": null}`, `{}`},
+		{`{"a": "b", "b": "c"}`, `{"a": null}`, `{"b": "c"}`},
+		{`{"a": ["b"]}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "c"}`, `{"a": ["b"]}`, `{"a": ["b"]}`},
+		{`{"a": {"b": "c"}}`, `{"a": {"b": "d", "c": null}}`, `{"a": {"b": "d"}}`},
+		{`{"a": [{"b": "c"}]}`, `{"a": [1]}`, `{"a": [1]}`},
+		{`["a", "b"]`, `["c", "d"]`, `["c", "d"]`},
+		{`{"a": "b"}`, `["c"]`, `["c"]`},
+		{`{"a": "foo"}`, `null`, `null`},
+		{`{"a": "foo"}`, `"bar"`, `"bar"`},
+		{`{"e": null}`, `{"a": 1}`, `{"e": null, "a": 1}`},
+		{`[1, 2]`, `{"a": "b", "c": null}`, `{"a": "b"}`},
+		{`{}`, `{"a": {"bb": {"ccc": null}}}`, `{"a": {"bb": {}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			got, err := apply(t, MergePatchContentType+"; charset=utf-8", tt.doc, tt.patch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     error
+	}{
+		{"merge patch", MergePatchContentType, `{"a": 1}`, nil},
+		{"json patch", JSONPatchContentType, `[]`, nil},
+		{"plain json", "application/json", `{"a": 1}`, ErrUnsupportedContentType},
+		{"malformed content type", "application/", `{}`, ErrUnsupportedContentType},
+		{"malformed merge patch", MergePatchContentType, `{"a":`, ErrInvalidPatch},
+		{"json patch that is not an array", JSONPatchContentType, `{"op": "add"}`, ErrInvalidPatch},
+		{"move with a bad from", JSONPatchContentType, `[{"op": "move", "from": "a", "path": "/b"}]`, ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.contentType, []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := DecodeStrict([]byte(`{"name": "x"}`), &v); err != nil || v.Name != "x" {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+	if err := DecodeStrict([]byte(`{"name": "x", "extra": 1}`), &v); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("got error %v, want %v", err, ErrInvalidPatch)
+	}
+}
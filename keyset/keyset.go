@@ -0,0 +1,253 @@
+package keyset
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// KeyConfig is one entry of the JWT_KEYS_FILE JSON array. HS256 keys use
+// Secret; RS256 and EdDSA keys use PEM files. A key without private material
+// can only verify, which is how retired keys stay valid during rotation.
+type KeyConfig struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"`
+	Secret         string `json:"secret,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	PublicKeyFile  string `json:"public_key_file,omitempty"`
+}
+
+type Key struct {
+	ID        string
+	Algorithm string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// Load builds the key set from the environment. JWT_KEYS_FILE points to a
+// JSON array of KeyConfig and JWT_SIGNING_KID picks the key that signs new
+// tokens. Without a keys file, JWT_SECRET is used as a single HS256 key.
+func Load() (*KeySet, error) {
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT_KEYS_FILE: %w", err)
+		}
+		var configs []KeyConfig
+		if err := json.Unmarshal(raw, &configs); err != nil {
+			return nil, fmt.Errorf("parse JWT_KEYS_FILE: %w", err)
+		}
+		return New(configs, os.Getenv("JWT_SIGNING_KID"))
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return New([]KeyConfig{{ID: "default", Algorithm: AlgHS256, Secret: secret}}, "default")
+	}
+	return nil, errors.New("no JWT signing key configured: set JWT_KEYS_FILE or JWT_SECRET")
+}
+
+// New builds a key set from configs. signingKID selects the signing key;
+// when empty the first key with private material is used.
+func New(configs []KeyConfig, signingKID string) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*Key{}}
+
+	for _, cfg := range configs {
+		if cfg.ID == "" {
+			return nil, errors.New("every JWT key needs a kid")
+		}
+		if _, dup := ks.keys[cfg.ID]; dup {
+			return nil, fmt.Errorf("duplicate JWT kid %q", cfg.ID)
+		}
+		key, err := parseKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q: %w", cfg.ID, err)
+		}
+		ks.keys[cfg.ID] = key
+
+		if ks.signing == nil && signingKID == "" && key.CanSign() {
+			ks.signing = key
+		}
+	}
+
+	if signingKID != "" {
+		key, ok := ks.keys[signingKID]
+		if !ok {
+			return nil, fmt.Errorf("signing kid %q not found", signingKID)
+		}
+		if !key.CanSign() {
+			return nil, fmt.Errorf("signing kid %q has no private key", signingKID)
+		}
+		ks.signing = key
+	}
+	if ks.signing == nil {
+		return nil, errors.New("no JWT key can sign tokens")
+	}
+	return ks, nil
+}
+
+func parseKey(cfg KeyConfig) (*Key, error) {
+	key := &Key{ID: cfg.ID, Algorithm: cfg.Algorithm}
+
+	switch cfg.Algorithm {
+	case AlgHS256:
+		if cfg.Secret == "" {
+			return nil, errors.New("HS256 key needs a secret")
+		}
+		if len(cfg.Secret) < 32 {
+			return nil, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = []byte(cfg.Secret)
+		key.verifyKey = []byte(cfg.Secret)
+
+	case AlgRS256:
+		key.method = jwt.SigningMethodRS256
+		if cfg.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey, key.verifyKey = priv, &priv.PublicKey
+		} else if cfg.PublicKeyFile != "" {
+			pem, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if key.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, errors.New("RS256 key needs private_key_file or public_key_file")
+		}
+
+	case AlgEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+		if cfg.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("EdDSA private key must be Ed25519")
+			}
+			key.signKey, key.verifyKey = edPriv, edPriv.Public()
+		} else if cfg.PublicKeyFile != "" {
+			pem, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if key.verifyKey, err = jwt.ParseEdPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, errors.New("EdDSA key needs private_key_file or public_key_file")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q (use HS256, RS256 or EdDSA)", cfg.Algorithm)
+	}
+	return key, nil
+}
+
+// Sign signs claims with the active signing key and sets the kid header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Keyfunc resolves the verification key for token by its kid header and
+// rejects tokens whose alg does not match the key, which blocks algorithm
+// confusion between HMAC and public keys.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q for kid %q", token.Method.Alg(), kid)
+	}
+	return key.verifyKey, nil
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public verification keys. HMAC keys are shared secrets
+// and are never published.
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		if jwk, ok := publicJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+func publicJWK(key *Key) (JWK, bool) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch pub := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA", KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig",
+			N: b64(pub.N.Bytes()),
+			E: b64(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: "OKP", KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig",
+			Curve: "Ed25519",
+			X:     b64(pub),
+		}, true
+	}
+	return JWK{}, false
+}
@@ -10,17 +10,46 @@ import (
 	"time"
 
 	"github.com/KRAZYFLASH/carZone/driver"
+	"github.com/KRAZYFLASH/carZone/keyset"
+	"github.com/KRAZYFLASH/carZone/models"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 
 	carHandler "github.com/KRAZYFLASH/carZone/handler/car"
 	engineHandler "github.com/KRAZYFLASH/carZone/handler/engine"
+	auditService "github.com/KRAZYFLASH/carZone/service/audit"
 	carService "github.com/KRAZYFLASH/carZone/service/car"
+	authService "github.com/KRAZYFLASH/carZone/service/auth"
+	customerService "github.com/KRAZYFLASH/carZone/service/customer"
 	engineService "github.com/KRAZYFLASH/carZone/service/engine"
+	leadService "github.com/KRAZYFLASH/carZone/service/lead"
+	orderService "github.com/KRAZYFLASH/carZone/service/order"
+	reservationService "github.com/KRAZYFLASH/carZone/service/reservation"
+	testDriveService "github.com/KRAZYFLASH/carZone/service/testdrive"
+	userService "github.com/KRAZYFLASH/carZone/service/user"
+	"github.com/KRAZYFLASH/carZone/store"
+	auditStore "github.com/KRAZYFLASH/carZone/store/audit"
 	carStore "github.com/KRAZYFLASH/carZone/store/car"
+	customerStore "github.com/KRAZYFLASH/carZone/store/customer"
 	engineStore "github.com/KRAZYFLASH/carZone/store/engine"
+	leadStore "github.com/KRAZYFLASH/carZone/store/lead"
+	"github.com/KRAZYFLASH/carZone/store/migrations"
+	orderStore "github.com/KRAZYFLASH/carZone/store/order"
+	reservationStore "github.com/KRAZYFLASH/carZone/store/reservation"
+	sessionStore "github.com/KRAZYFLASH/carZone/store/session"
+	testDriveStore "github.com/KRAZYFLASH/carZone/store/testdrive"
+	userStore "github.com/KRAZYFLASH/carZone/store/user"
 
+	auditHandler "github.com/KRAZYFLASH/carZone/handler/audit"
+	customerHandler "github.com/KRAZYFLASH/carZone/handler/customer"
+	jwksHandler "github.com/KRAZYFLASH/carZone/handler/jwks"
+	leadHandler "github.com/KRAZYFLASH/carZone/handler/lead"
 	loginHandler "github.com/KRAZYFLASH/carZone/handler/login"
+	orderHandler "github.com/KRAZYFLASH/carZone/handler/order"
+	reservationHandler "github.com/KRAZYFLASH/carZone/handler/reservation"
+	testDriveHandler "github.com/KRAZYFLASH/carZone/handler/testdrive"
+	userHandler "github.com/KRAZYFLASH/carZone/handler/user"
+	validationHandler "github.com/KRAZYFLASH/carZone/handler/validation"
 	middleware "github.com/KRAZYFLASH/carZone/middleware"
 
 	"go.opentelemetry.io/otel"
@@ -39,8 +68,19 @@ func main() {
 		log.Println("warning: .env not found; continuing")
 	}
 
-	// --- Tracing init (sekali saja) ---
 	ctx := context.Background()
+
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		driver.InitDB()
+		err := runCommand(ctx, driver.GetDB(), os.Args[1:])
+		driver.CloseDB()
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// --- Tracing init (sekali saja) ---
 	tp, err := startTracing(ctx)
 	if err != nil {
 		log.Fatalf("tracing init: %v", err)
@@ -55,40 +95,175 @@ func main() {
 
 	db := driver.GetDB()
 
+	// Migrasi aman dijalankan dari banyak replika sekaligus (advisory lock).
+	// Set AUTO_MIGRATE=false kalau migrasi dijalankan terpisah lewat CLI.
+	if os.Getenv("AUTO_MIGRATE") != "false" {
+		if err := migrateUp(ctx, db); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+	}
+
+	tx := store.NewTransactor(db)
+	aus := auditStore.New(db)
+	ausvc := auditService.NewAuditService(aus)
+
 	cs := carStore.New(db)
-	csvc := carService.NewCarService(cs)
+	csvc := carService.NewCarService(cs, tx, ausvc)
 	es := engineStore.New(db)
-	esvc := engineService.NewEngineService(es)
+	esvc := engineService.NewEngineService(es, csvc, tx, ausvc)
+	rs := reservationStore.New(db)
+	rsvc := reservationService.NewReservationService(rs, csvc, tx, ausvc)
+	ors := orderStore.New(db)
+	osvc := orderService.NewOrderService(ors, csvc, tx, ausvc)
+	cus := customerStore.New(db)
+	cusvc := customerService.NewCustomerService(cus, tx, ausvc)
+	ls := leadStore.New(db)
+	lsvc := leadService.NewLeadService(ls, tx, ausvc)
+
+	keys, err := keyset.Load()
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
+	us := userStore.New(db)
+	ss := sessionStore.New(db)
+	usvc := userService.NewUserService(us, ss)
+	tds := testDriveStore.New(db)
+	tdsvc := testDriveService.NewTestDriveService(tds, cs, usvc, tx, ausvc)
+	asvc := authService.NewAuthService(usvc, ss, keys,
+		durationEnv("ACCESS_TOKEN_TTL", authService.DefaultAccessTTL),
+		durationEnv("REFRESH_TOKEN_TTL", authService.DefaultRefreshTTL),
+	)
+	go asvc.RunCleanup(ctx, time.Hour)
+
+	// Data di trash dihapus permanen setelah TRASH_RETENTION (default 30 hari).
+	retention := durationEnv("TRASH_RETENTION", 30*24*time.Hour)
+	go csvc.RunPurge(ctx, time.Hour, retention)
+	go esvc.RunPurge(ctx, time.Hour, retention)
+	// Hold yang lewat batas waktu dilepas otomatis.
+	go rsvc.RunExpirer(ctx, time.Minute)
+
+	// Akun admin awal diambil dari ENV, hanya dibuat kalau belum ada. Tidak
+	// ada password bawaan: ADMIN_PASSWORD wajib diisi.
+	if adminUser := os.Getenv("ADMIN_USERNAME"); adminUser != "" {
+		adminPassword := os.Getenv("ADMIN_PASSWORD")
+		if adminPassword == "" {
+			log.Fatal("ADMIN_PASSWORD must be set when ADMIN_USERNAME is set")
+		}
+		if err := usvc.EnsureUser(ctx, adminUser, adminPassword, models.RoleAdmin); err != nil {
+			log.Fatalf("Failed to provision admin user: %v", err)
+		}
+	}
 
 	ch := carHandler.NewCarHandler(csvc)
 	eh := engineHandler.NewEngineHandler(esvc)
+	rh := reservationHandler.NewReservationHandler(rsvc)
+	oh := orderHandler.NewOrderHandler(osvc)
+	cuh := customerHandler.NewCustomerHandler(cusvc)
+	leh := leadHandler.NewLeadHandler(lsvc)
+	tdh := testDriveHandler.NewTestDriveHandler(tdsvc)
+	lh := loginHandler.NewLoginHandler(asvc)
+	uh := userHandler.NewUserHandler(usvc)
+	jh := jwksHandler.NewJWKSHandler(keys)
+	vh := validationHandler.NewValidationHandler()
+	ah := auditHandler.NewAuditHandler(ausvc)
 
 	router := mux.NewRouter()
 
+	router.Use(middleware.RequestID)
 	router.Use(otelmux.Middleware("CarZone"))
 	router.Use(middleware.MetricsMiddleware)
 
 
+	router.HandleFunc("/login", lh.Login).Methods("POST")
+	router.HandleFunc("/token/refresh", lh.Refresh).Methods("POST")
+	router.HandleFunc("/.well-known/jwks.json", jh.GetJWKS).Methods("GET")
+
+	protected := router.PathPrefix("/").Subrouter()
+	protected.Use(middleware.NewAuthMiddleware(keys, asvc))
 
-	if err := executeSchemaFile(db, "store/schema.sql"); err != nil {
-		log.Fatalf("Failed to execute schema: %v", err)
+	protected.HandleFunc("/logout", lh.Logout).Methods("POST")
+	protected.HandleFunc("/validation/rules", vh.GetRules).Methods("GET")
+
+	// allow membungkus handler dengan pengecekan permission berbasis role.
+	allow := func(p models.Permission, h http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(p)(h)
 	}
 
-	router.HandleFunc("/login", loginHandler.LoginHandler).Methods("POST")
+	// Rute statis didaftarkan sebelum /cars/{id} supaya tidak tertangkap sebagai id.
+	protected.Handle("/cars/trash", allow(models.PermCarWrite, ch.ListTrash)).Methods("GET")
+	protected.Handle("/cars/export", allow(models.PermCarRead, ch.ExportCars)).Methods("GET")
+	protected.Handle("/cars/search", allow(models.PermCarRead, ch.SearchCars)).Methods("GET")
+	protected.Handle("/cars/{id}", allow(models.PermCarRead, ch.GetCarById)).Methods("GET")
+	protected.Handle("/cars", allow(models.PermCarRead, ch.ListCars)).Methods("GET")
+	protected.Handle("/cars", allow(models.PermCarWrite, ch.CreateCar)).Methods("POST")
+	protected.Handle("/cars/import", allow(models.PermCarWrite, ch.ImportCars)).Methods("POST")
+	protected.Handle("/cars/batch", allow(models.PermCarWrite, ch.BatchCars)).Methods("POST")
+	protected.Handle("/cars/{id}", allow(models.PermCarWrite, ch.UpdateCar)).Methods("PUT")
+	protected.Handle("/cars/{id}", allow(models.PermCarWrite, ch.PatchCar)).Methods("PATCH")
+	protected.Handle("/cars/{id}", allow(models.PermCarWrite, ch.DeleteCar)).Methods("DELETE")
+	protected.Handle("/cars/{id}/restore", allow(models.PermCarWrite, ch.RestoreCar)).Methods("POST")
+	protected.Handle("/cars/{id}/history", allow(models.PermCarRead, ch.ListCarHistory)).Methods("GET")
+	protected.Handle("/cars/{id}/revert", allow(models.PermCarWrite, ch.RevertCar)).Methods("POST")
+	protected.Handle("/cars/{id}/status-history", allow(models.PermCarRead, ch.ListStatusChanges)).Methods("GET")
+	for action, t := range models.CarTransitions {
+		if models.WorkflowActions[action] {
+			continue
+		}
+		protected.Handle("/cars/{id}/"+action, allow(t.Permission, ch.TransitionCar(action))).Methods("POST")
+	}
+	protected.Handle("/cars/{id}/reservations", allow(models.PermCarRead, rh.ListReservations)).Methods("GET")
+	protected.Handle("/cars/{id}/reservations", allow(models.PermCarSell, rh.CreateReservation)).Methods("POST")
+	protected.Handle("/reservations/{id}", allow(models.PermCarRead, rh.GetReservation)).Methods("GET")
+	protected.Handle("/reservations/{id}/release", allow(models.PermCarSell, rh.ReleaseReservation)).Methods("POST")
+	protected.Handle("/cars/{id}/test-drives", allow(models.PermCarRead, tdh.ListTestDrives)).Methods("GET")
+	protected.Handle("/cars/{id}/test-drives", allow(models.PermCarSell, tdh.CreateTestDrive)).Methods("POST")
+	protected.Handle("/test-drives/{id}", allow(models.PermCarRead, tdh.GetTestDrive)).Methods("GET")
+	protected.Handle("/test-drives/{id}/reschedule", allow(models.PermCarSell, tdh.RescheduleTestDrive)).Methods("POST")
+	protected.Handle("/test-drives/{id}/cancel", allow(models.PermCarSell, tdh.CancelTestDrive)).Methods("POST")
+	protected.Handle("/salespeople/{username}/test-drives.ics", allow(models.PermCarSell, tdh.SalespersonCalendar)).Methods("GET")
 
-	protected := router.PathPrefix("/").Subrouter()
-	protected.Use(middleware.AuthMiddleware)
+	protected.Handle("/engine/usage", allow(models.PermEngineRead, eh.EngineUsage)).Methods("GET")
+	protected.Handle("/engine/{id}", allow(models.PermEngineRead, eh.GetEngineById)).Methods("GET")
+	protected.Handle("/engine", allow(models.PermEngineRead, eh.ListEngines)).Methods("GET")
+	protected.Handle("/engine", allow(models.PermEngineWrite, eh.CreateEngine)).Methods("POST")
+	protected.Handle("/engine/{id}", allow(models.PermEngineWrite, eh.UpdateEngine)).Methods("PUT")
+	protected.Handle("/engine/{id}", allow(models.PermEngineWrite, eh.PatchEngine)).Methods("PATCH")
+	protected.Handle("/engine/{id}", allow(models.PermEngineWrite, eh.DeleteEngine)).Methods("DELETE")
+	protected.Handle("/engine/{id}/cars", allow(models.PermCarRead, ch.ListEngineCars)).Methods("GET")
+	protected.Handle("/engine/{id}/restore", allow(models.PermEngineWrite, eh.RestoreEngine)).Methods("POST")
 
-	protected.HandleFunc("/cars/{id}", ch.GetCarById).Methods("GET")
-	protected.HandleFunc("/cars", ch.GetCarByBrand).Methods("GET")
-	protected.HandleFunc("/cars", ch.CreateCar).Methods("POST")
-	protected.HandleFunc("/cars/{id}", ch.UpdateCar).Methods("PUT")
-	protected.HandleFunc("/cars/{id}", ch.DeleteCar).Methods("DELETE")
+	protected.Handle("/orders", allow(models.PermCarSell, oh.ListOrders)).Methods("GET")
+	protected.Handle("/orders", allow(models.PermCarSell, oh.CreateOrder)).Methods("POST")
+	protected.Handle("/orders/{id}", allow(models.PermCarSell, oh.GetOrder)).Methods("GET")
+	protected.Handle("/orders/{id}", allow(models.PermCarSell, oh.UpdateOrder)).Methods("PUT")
+	protected.Handle("/orders/{id}", allow(models.PermCarSell, oh.CancelOrder)).Methods("DELETE")
+	protected.Handle("/orders/{id}/payment", allow(models.PermCarSell, oh.SetPaymentStatus)).Methods("PUT")
+	protected.Handle("/orders/{id}/complete", allow(models.PermCarSell, oh.CompleteOrder)).Methods("POST")
+	protected.Handle("/orders/{id}/invoice", allow(models.PermCarSell, oh.GetInvoice)).Methods("GET")
 
-	protected.HandleFunc("/engine/{id}", eh.GetEngineById).Methods("GET")
-	protected.HandleFunc("/engine", eh.CreateEngine).Methods("POST")
-	protected.HandleFunc("/engine/{id}", eh.UpdateEngine).Methods("PUT")
-	protected.HandleFunc("/engine/{id}", eh.DeleteEngine).Methods("DELETE")
+	protected.Handle("/customers", allow(models.PermCustomerManage, cuh.ListCustomers)).Methods("GET")
+	protected.Handle("/customers", allow(models.PermCustomerManage, cuh.CreateCustomer)).Methods("POST")
+	protected.Handle("/customers/{id}", allow(models.PermCustomerManage, cuh.GetCustomer)).Methods("GET")
+	protected.Handle("/customers/{id}", allow(models.PermCustomerManage, cuh.UpdateCustomer)).Methods("PUT")
+	protected.Handle("/customers/{id}", allow(models.PermCustomerManage, cuh.DeleteCustomer)).Methods("DELETE")
+	protected.Handle("/customers/{id}/notes", allow(models.PermCustomerManage, cuh.ListNotes)).Methods("GET")
+	protected.Handle("/customers/{id}/notes", allow(models.PermCustomerManage, cuh.AddNote)).Methods("POST")
+
+	protected.Handle("/leads", allow(models.PermCustomerManage, leh.ListLeads)).Methods("GET")
+	protected.Handle("/leads", allow(models.PermCustomerManage, leh.CreateLead)).Methods("POST")
+	protected.Handle("/leads/{id}", allow(models.PermCustomerManage, leh.GetLead)).Methods("GET")
+	protected.Handle("/leads/{id}", allow(models.PermCustomerManage, leh.UpdateLead)).Methods("PUT")
+	protected.Handle("/leads/{id}", allow(models.PermCustomerManage, leh.DeleteLead)).Methods("DELETE")
+	protected.Handle("/leads/{id}/assign", allow(models.PermCustomerManage, leh.AssignLead)).Methods("POST")
+	protected.Handle("/leads/{id}/unassign", allow(models.PermCustomerManage, leh.UnassignLead)).Methods("POST")
+
+	protected.Handle("/users", allow(models.PermUserManage, uh.Register)).Methods("POST")
+	protected.Handle("/users/me/password", allow(models.PermOwnAccount, uh.ChangePassword)).Methods("PUT")
+	protected.Handle("/users/{username}/deactivate", allow(models.PermUserManage, uh.Deactivate)).Methods("POST")
+	protected.Handle("/users/{username}/role", allow(models.PermUserManage, uh.ChangeRole)).Methods("PUT")
+
+	protected.Handle("/audit", allow(models.PermAuditRead, ah.ListAudit)).Methods("GET")
 
 	router.Handle("/metrics", promhttp.Handler())
 
@@ -100,15 +275,29 @@ func main() {
 	log.Fatal(http.ListenAndServe(addr, router))
 }
 
-func executeSchemaFile(db *sql.DB, fileName string) error {
-	sqlfile, err := os.ReadFile(fileName)
+func migrateUp(ctx context.Context, db *sql.DB) error {
+	m, err := migrations.New(db)
 	if err != nil {
-		return fmt.Errorf("failed to read schema file: %v", err)
+		return err
+	}
+	applied, err := m.Up(ctx)
+	for _, mig := range applied {
+		log.Printf("Applied migration %04d_%s", mig.Version, mig.Name)
+	}
+	return err
+}
+
+// durationEnv reads a time.Duration such as "15m" from the environment.
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
 	}
-	if _, err = db.Exec(string(sqlfile)); err != nil {
-		return fmt.Errorf("failed to execute schema: %v", err)
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("invalid %s %q: must be a positive duration like 15m", key, v)
 	}
-	return nil
+	return d
 }
 
 func startTracing(ctx context.Context) (*sdktrace.TracerProvider, error) {
@@ -2,58 +2,127 @@ package middleware
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/KRAZYFLASH/carZone/handler"
+	"github.com/KRAZYFLASH/carZone/models"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwtKey = []byte("some_value") // TODO: ganti ke ENV: os.Getenv("JWT_SECRET")
-
 // Gunakan RegisteredClaims (v4), bukan StandardClaims
 type Claims struct {
-	Username string `json:"username"`
+	Username  string      `json:"username"`
+	Role      models.Role `json:"role"`
+	SessionID string      `json:"sid"`
 	jwt.RegisteredClaims
 }
 
+// KeyResolver returns the verification key for a parsed token (see
+// keyset.KeySet.Keyfunc).
+type KeyResolver interface {
+	Keyfunc(token *jwt.Token) (interface{}, error)
+}
+
+// RevocationChecker reports whether an access token (by jti) or the session
+// it belongs to has been revoked.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti, sessionID string) (bool, error)
+}
+
 // Hindari string sebagai context key
 type ctxKey string
 
-const userCtxKey ctxKey = "username"
+const (
+	userCtxKey   ctxKey = "username"
+	roleCtxKey   ctxKey = "role"
+	claimsCtxKey ctxKey = "claims"
+)
+
+// NewAuthMiddleware validates the bearer token against keys and rejects
+// tokens whose jti or session was revoked through revocations.
+func NewAuthMiddleware(keys KeyResolver, revocations RevocationChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authMiddleware(keys, revocations, next)
+	}
+}
 
-func AuthMiddleware(next http.Handler) http.Handler {
+func authMiddleware(keys KeyResolver, revocations RevocationChecker, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+			handler.WriteError(w, models.Unauthorized("Missing Authorization header"))
 			return
 		}
 		if !strings.HasPrefix(authHeader, "Bearer") {
-			http.Error(w, "Invalid Authorization scheme", http.StatusUnauthorized)
+			handler.WriteError(w, models.Unauthorized("Invalid Authorization scheme"))
 			return
 		}
 
 		tokenString := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
 
 		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Pastikan algoritma yang dipakai sesuai (HMAC/HS256)
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtKey, nil
-		})
+		// Key dipilih lewat header kid; algoritma harus cocok dengan key tersebut.
+		token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc)
 
 		if err != nil || token == nil || !token.Valid {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			handler.WriteError(w, models.Unauthorized("Invalid or expired token"))
 			return
 		}
 
 		// (Optional) Validasi tambahan iss/aud kalau kalian set saat membuat token
 		// if claims.Issuer != "your-issuer" { ... }
 
+		// Token tanpa jti/sid/exp berasal dari sebelum ada sesi dan tidak bisa dicabut.
+		if claims.ID == "" || claims.SessionID == "" || claims.ExpiresAt == nil {
+			handler.WriteError(w, models.Unauthorized("Invalid or expired token"))
+			return
+		}
+		revoked, err := revocations.IsRevoked(r.Context(), claims.ID, claims.SessionID)
+		if err != nil {
+			handler.WriteError(w, err)
+			return
+		}
+		if revoked {
+			handler.WriteError(w, models.Unauthorized("Token has been revoked"))
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), userCtxKey, claims.Username)
+		ctx = context.WithValue(ctx, roleCtxKey, claims.Role)
+		ctx = context.WithValue(ctx, claimsCtxKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// UsernameFromContext returns the authenticated username set by AuthMiddleware.
+func UsernameFromContext(ctx context.Context) (string, bool) {
+	username, ok := ctx.Value(userCtxKey).(string)
+	return username, ok && username != ""
+}
+
+// ClaimsFromContext returns the validated token claims set by AuthMiddleware.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsCtxKey).(*Claims)
+	return claims, ok
+}
+
+// RoleFromContext returns the role carried by the authenticated token.
+func RoleFromContext(ctx context.Context) models.Role {
+	role, _ := ctx.Value(roleCtxKey).(models.Role)
+	return role
+}
+
+// RequirePermission only lets the request through when the caller's role
+// grants p. It must run after AuthMiddleware.
+func RequirePermission(p models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !RoleFromContext(r.Context()).Can(p) {
+				handler.WriteError(w, models.Forbidden("missing permission "+string(p)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
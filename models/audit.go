@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditCreated       = "created"
+	AuditUpdated       = "updated"
+	AuditDeleted       = "deleted"
+	AuditRestored      = "restored"
+	AuditReverted      = "reverted"
+	AuditStatusChanged = "status_changed"
+	AuditExpired       = "expired"
+)
+
+const (
+	EntityCar         = "car"
+	EntityEngine      = "engine"
+	EntityReservation = "reservation"
+	EntityOrder       = "order"
+	EntityCustomer    = "customer"
+	EntityLead        = "lead"
+	EntityTestDrive   = "test_drive"
+)
+
+// AuditEntities are the entity types accepted by AuditFilter.
+var AuditEntities = []string{EntityCar, EntityEngine, EntityReservation, EntityOrder, EntityCustomer, EntityLead, EntityTestDrive}
+
+// Change is the old and new value of one field; nil means absent.
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type AuditEntry struct {
+	ID         int64             `json:"id"`
+	Actor      string            `json:"actor"`
+	Action     string            `json:"action"`
+	EntityType string            `json:"entity_type"`
+	EntityID   uuid.UUID         `json:"entity_id"`
+	Before     json.RawMessage   `json:"before,omitempty"`
+	After      json.RawMessage   `json:"after,omitempty"`
+	Changes    map[string]Change `json:"changes"`
+	RequestID  string            `json:"request_id,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// AuditFilter selects audit entries, newest first. A nil EntityID returns
+// entries for every entity of EntityType.
+type AuditFilter struct {
+	EntityType string
+	EntityID   *uuid.UUID
+	Cursor     string
+	Limit      int
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
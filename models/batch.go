@@ -0,0 +1,62 @@
+package models
+
+import "github.com/google/uuid"
+
+const MaxBatchOperations = 1000
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+const (
+	BatchOpSucceeded  = "succeeded"
+	BatchOpFailed     = "failed"
+	BatchOpRolledBack = "rolled_back"
+	BatchOpSkipped    = "skipped"
+)
+
+// BatchOperation is one step of POST /cars/batch. Version, when set, must
+// match the car's current version, like an If-Match header.
+type BatchOperation struct {
+	Op      string      `json:"op"`
+	ID      string      `json:"id,omitempty"`
+	Version *int        `json:"version,omitempty"`
+	Car     *CarRequest `json:"car,omitempty"`
+}
+
+type BatchRequest struct {
+	ContinueOnError bool             `json:"continue_on_error"`
+	Operations      []BatchOperation `json:"operations"`
+}
+
+type BatchResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	Status string       `json:"status"`
+	ID     *uuid.UUID   `json:"id,omitempty"`
+	Car    *Car         `json:"car,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// BatchReport is the per-operation outcome of a batch. Committed is false
+// when the transaction was rolled back and nothing was saved.
+type BatchReport struct {
+	Committed bool          `json:"committed"`
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+func ValidateBatchRequest(req BatchRequest) error {
+	switch {
+	case len(req.Operations) == 0:
+		return Validation("batch has no operations", FieldError{Field: "operations", Rule: "required", Message: "at least one operation is required"})
+	case len(req.Operations) > MaxBatchOperations:
+		return Validation("batch has too many operations", FieldError{Field: "operations", Rule: "max", Message: "at most 1000 operations are allowed"})
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package models
 
 import (
-	"errors"
 	"strconv"
 	"time"
 
@@ -16,119 +15,144 @@ type Car struct {
 	FuelType string   `json:"fuel_type"`
 	Engine Engine   `json:"engine"`
 	Price float64  `json:"price"`
+	Status string `json:"status"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	StatusChangedBy string `json:"status_changed_by,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version int `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy string `json:"deleted_by,omitempty"`
 }
 
+// CarRequest describes a car to save. The engine is either given inline or
+// by EngineID, which reuses an existing engine; EngineID takes precedence and
+// the inline engine is then ignored. Status only applies when the car is
+// created; afterwards it changes through the transition endpoints.
 type CarRequest struct {
 	Name  string    `json:"name"`
 	Brand string    `json:"brand"`
 	Year  string    `json:"year"`
 	FuelType string   `json:"fuelType"`
+	EngineID string `json:"engine_id,omitempty"`
 	Engine EngineRequest   `json:"engine"`
 	Price float64  `json:"price"`
+	Status string `json:"status,omitempty"`
 }
 
-
-func ValidateRequest(carReq CarRequest) error {
-	if err := validateName(carReq.Name); err != nil {
-		return err
+// Request returns the writable fields of c in CarRequest form, the document
+// that PATCH requests are applied to.
+func (c Car) Request() CarRequest {
+	return CarRequest{
+		Name:     c.Name,
+		Brand:    c.Brand,
+		Year:     c.Year,
+		FuelType: c.FuelType,
+		Engine:   c.Engine.Request(),
+		Price:    c.Price,
 	}
-
-	if err := validateBrand(carReq.Brand); err != nil {
-		return err
-	}
-
-	if err := validateYear(carReq.Year); err != nil {
-		return err
-	}
-
-	if err := validateFuelType(carReq.FuelType); err != nil {
-		return err
-	}
-
-	if err := ValidateEngineRequest(carReq.Engine); err != nil {
-		return err
-	}
-
-	if err := validatePrice(carReq.Price); err != nil {
-		return err
-	}
-
-	return nil
 }
 
+const MinCarYear = 1886
 
+var FuelTypes = []string{"Petrol", "Diesel", "Electric", "Hybrid"}
 
-func validateName(name string) error {
-	if name == "" {
-		return errors.New("Name cannot be empty")
-	}
-	return nil
+// CarRequestRules builds the rule set for CarRequest. It is rebuilt per call
+// because the upper bound on year moves with the calendar.
+func CarRequestRules() RuleSet[CarRequest] {
+	return carRequestRules(FuelEngineRules())
 }
 
-func validateYear(year string) error {
-	if year == "" {
-		return errors.New("Year cannot be empty")
-	}
+// UpdateRequestRules is CarRequestRules for replacing before; its inline
+// engine is checked with FuelEngineChangeRules.
+func UpdateRequestRules(before Car) RuleSet[CarRequest] {
+	return carRequestRules(FuelEngineChangeRules(FuelEngine{FuelType: before.FuelType, Engine: before.Engine.Request()}))
+}
 
-	_, err := strconv.Atoi(year)
-	if err != nil {
-		return errors.New("Year must be a valid number")
-	}
-	
+func carRequestRules(fuelRules RuleSet[FuelEngine]) RuleSet[CarRequest] {
 	currentYear := time.Now().Year()
-	yearInt, _ := strconv.Atoi(year)
 
-	if yearInt < 1886 || yearInt > currentYear {
-		return errors.New("Year must be between 1886 and the current year")
-	}
-	return nil
+	rules := RuleSet[CarRequest]{
+		required("name", "Name", func(c CarRequest) string { return c.Name }),
+		required("brand", "Brand", func(c CarRequest) string { return c.Brand }),
+		required("year", "Year", func(c CarRequest) string { return c.Year }),
+		{
+			Field:   "year",
+			Name:    "integer",
+			Message: "Year must be a valid number",
+			Valid: func(c CarRequest) bool {
+				_, err := strconv.Atoi(c.Year)
+				return err == nil
+			},
+		},
+		{
+			Field:   "year",
+			Name:    "between",
+			Params:  map[string]any{"min": MinCarYear, "max": currentYear},
+			Message: "Year must be between 1886 and the current year",
+			Valid: func(c CarRequest) bool {
+				year, _ := strconv.Atoi(c.Year)
+				return year >= MinCarYear && year <= currentYear
+			},
+		},
+		oneOf("fuelType", "Fuel type", FuelTypes, func(c CarRequest) string { return c.FuelType }),
+		positive("price", "Price", func(c CarRequest) float64 { return c.Price }),
+		optionalOneOf("status", "Status", InitialCarStatuses, func(c CarRequest) string { return c.Status }),
+	}
+
+	rules = append(rules, Rule[CarRequest]{
+		Field:   "engine_id",
+		Name:    "uuid",
+		Message: "Engine ID must be a valid UUID",
+		Valid: func(c CarRequest) bool {
+			if c.EngineID == "" {
+				return true
+			}
+			_, err := uuid.Parse(c.EngineID)
+			return err == nil
+		},
+	})
+
+	// The inline engine is only checked when no existing engine is referenced;
+	// a referenced engine is checked against the fuel type when it is loaded.
+	engineRules := append(
+		Nest("engine", EngineRequestRules(), func(c CarRequest) EngineRequest { return c.Engine }),
+		Nest("engine", fuelRules, func(c CarRequest) FuelEngine { return FuelEngine{FuelType: c.FuelType, Engine: c.Engine} })...,
+	)
+	for _, r := range engineRules {
+		valid := r.Valid
+		r.Valid = func(c CarRequest) bool { return c.EngineID != "" || valid(c) }
+		rules = append(rules, r)
+	}
+	return rules
 }
 
-func validateBrand(brand string) error {
-	if brand == "" {
-		return errors.New("Brand cannot be empty")
-	}
-	return nil
+// ValidateRequest checks every field of carReq and reports all violations
+// in one Validation error.
+func ValidateRequest(carReq CarRequest) error {
+	return Check(CarRequestRules(), carReq)
 }
 
-func validateFuelType(fuelType string) error {
-	validateFuelTypes:= []string{"Petrol", "Diesel", "Electric", "Hybrid"}
-	for _, validType := range validateFuelTypes {
-		if fuelType == validType {
-			return nil
-		}
-	}
-	return errors.New("Fuel type must be one of the following: Petrol, Diesel, Electric, Hybrid")
+// ValidateUpdateRequest checks carReq as a replacement for before.
+func ValidateUpdateRequest(carReq CarRequest, before Car) error {
+	return Check(UpdateRequestRules(before), carReq)
 }
 
-func validateEngine(engine Engine) error {
-	if engine.EngineID == uuid.Nil {
-		return errors.New("Engine ID cannot be empty")
-	}
-
-	if engine.Displacement <= 0 {
-		return errors.New("Displacement must be a positive number")
-	}
-
-	if engine.NoOfCylinders <= 0 {
-		return errors.New("Number of cylinders must be a positive number")
-	}
-
-	if engine.CarRange <= 0 {
-		return errors.New("Car range must be a positive number")
-	}
-
-	return nil
+// CarRevision is one stored version of a car, with its engine as it was
+// when that version was written.
+type CarRevision struct {
+	Version   int       `json:"version"`
+	ValidFrom time.Time `json:"valid_from"`
+	Car       Car       `json:"car"`
 }
 
-
-func validatePrice(price float64) error {
-	if price <= 0 {
-		return errors.New("Price must be Greater than Zero")
-	}
-
-	return nil
+// RevertRequest names the version a car is reverted to.
+type RevertRequest struct {
+	Version int `json:"version"`
 }
 
+func ValidateRevertRequest(req RevertRequest) error {
+	return Check(RuleSet[RevertRequest]{
+		positive("version", "Version", func(r RevertRequest) float64 { return float64(r.Version) }),
+	}, req)
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxInterestedCars caps the cars one customer can be interested in.
+const MaxInterestedCars = 50
+
+// Consent records which channels a customer agreed to be contacted on.
+type Consent struct {
+	Email bool `json:"email"`
+	SMS   bool `json:"sms"`
+	Phone bool `json:"phone"`
+}
+
+type Customer struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email,omitempty"`
+	Phone   string    `json:"phone,omitempty"`
+	Address string    `json:"address,omitempty"`
+	Consent Consent   `json:"consent"`
+	// ConsentUpdatedAt is when Consent last changed, as proof of when it
+	// was given or withdrawn.
+	ConsentUpdatedAt *time.Time `json:"consent_updated_at,omitempty"`
+	InterestedCars   []string   `json:"interested_cars"`
+	CreatedBy        string     `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Version          int        `json:"version"`
+}
+
+type CustomerRequest struct {
+	Name           string   `json:"name"`
+	Email          string   `json:"email,omitempty"`
+	Phone          string   `json:"phone,omitempty"`
+	Address        string   `json:"address,omitempty"`
+	Consent        Consent  `json:"consent"`
+	InterestedCars []string `json:"interested_cars"`
+}
+
+// CustomerNote is a free-text entry on a customer's timeline.
+type CustomerNote struct {
+	ID         int64     `json:"id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	Body       string    `json:"body"`
+	Author     string    `json:"author"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CustomerNoteRequest struct {
+	Body string `json:"body"`
+}
+
+// CustomerFilter selects customers by name, email or phone (Query, a
+// case-insensitive substring) and by a car they are interested in.
+type CustomerFilter struct {
+	Query  string
+	CarID  string
+	Cursor string
+	Limit  int
+}
+
+type CustomerPage struct {
+	Customers  []Customer `json:"customers"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+func CustomerRequestRules() RuleSet[CustomerRequest] {
+	return RuleSet[CustomerRequest]{
+		required("name", "Name", func(r CustomerRequest) string { return r.Name }),
+		email("email", "Email", func(r CustomerRequest) string { return r.Email }),
+		{
+			Field:   "email",
+			Name:    "contact",
+			Message: "A customer needs an email or a phone number",
+			Valid: func(r CustomerRequest) bool {
+				return strings.TrimSpace(r.Email) != "" || strings.TrimSpace(r.Phone) != ""
+			},
+		},
+		{
+			Field:   "consent.email",
+			Name:    "requires",
+			Params:  map[string]any{"field": "email"},
+			Message: "Email consent requires an email address",
+			Valid:   func(r CustomerRequest) bool { return !r.Consent.Email || r.Email != "" },
+		},
+		{
+			Field:   "consent.sms",
+			Name:    "requires",
+			Params:  map[string]any{"field": "phone"},
+			Message: "SMS consent requires a phone number",
+			Valid:   func(r CustomerRequest) bool { return !r.Consent.SMS || r.Phone != "" },
+		},
+		{
+			Field:   "consent.phone",
+			Name:    "requires",
+			Params:  map[string]any{"field": "phone"},
+			Message: "Phone consent requires a phone number",
+			Valid:   func(r CustomerRequest) bool { return !r.Consent.Phone || r.Phone != "" },
+		},
+		{
+			Field:   "interested_cars",
+			Name:    "max_items",
+			Params:  map[string]any{"value": MaxInterestedCars},
+			Message: fmt.Sprintf("A customer can be interested in at most %d cars", MaxInterestedCars),
+			Valid:   func(r CustomerRequest) bool { return len(r.InterestedCars) <= MaxInterestedCars },
+		},
+		{
+			Field:   "interested_cars",
+			Name:    "uuid",
+			Message: "Interested cars must be valid car IDs",
+			Valid: func(r CustomerRequest) bool {
+				for _, id := range r.InterestedCars {
+					if _, err := uuid.Parse(id); err != nil {
+						return false
+					}
+				}
+				return true
+			},
+		},
+	}
+}
+
+func ValidateCustomerRequest(req CustomerRequest) error {
+	return Check(CustomerRequestRules(), req)
+}
+
+func CustomerNoteRequestRules() RuleSet[CustomerNoteRequest] {
+	return RuleSet[CustomerNoteRequest]{
+		required("body", "Note", func(r CustomerNoteRequest) string { return r.Body }),
+	}
+}
+
+func ValidateCustomerNoteRequest(req CustomerNoteRequest) error {
+	return Check(CustomerNoteRequestRules(), req)
+}
@@ -1,7 +1,7 @@
 package models
 
 import (
-	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,44 +12,213 @@ type Engine struct {
 	Displacement int64   `json:"displacement"`
 	NoOfCylinders int     `json:"noOfCylinders"`
 	CarRange	int64   `json:"carRange"`
+	Horsepower       int     `json:"horsepower"`
+	Torque           int     `json:"torque"`
+	Transmission     string  `json:"transmission,omitempty"`
+	BatteryKWh       float64 `json:"batteryKwh"`
+	ChargingStandard string  `json:"chargingStandard,omitempty"`
+	EmissionsClass   string  `json:"emissionsClass,omitempty"`
+	Version int `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy string `json:"deleted_by,omitempty"`
 }
 
+// EngineRequest is the writable part of an engine. Horsepower is in hp,
+// Torque in Nm and BatteryKWh in kWh; zero or empty means not specified.
 type EngineRequest struct {
 	Displacement int64   `json:"displacement"`
 	NoOfCylinders int     `json:"noOfCylinders"`
 	CarRange	int64   `json:"carRange"`
+	Horsepower       int     `json:"horsepower"`
+	Torque           int     `json:"torque"`
+	Transmission     string  `json:"transmission,omitempty"`
+	BatteryKWh       float64 `json:"batteryKwh"`
+	ChargingStandard string  `json:"chargingStandard,omitempty"`
+	EmissionsClass   string  `json:"emissionsClass,omitempty"`
 }
 
-func ValidateEngineRequest(engineReq EngineRequest) error {
-	if err := validateDisplacement(engineReq.Displacement); err != nil {
-		return err
+func (e Engine) Request() EngineRequest {
+	return EngineRequest{
+		Displacement:     e.Displacement,
+		NoOfCylinders:    e.NoOfCylinders,
+		CarRange:         e.CarRange,
+		Horsepower:       e.Horsepower,
+		Torque:           e.Torque,
+		Transmission:     e.Transmission,
+		BatteryKWh:       e.BatteryKWh,
+		ChargingStandard: e.ChargingStandard,
+		EmissionsClass:   e.EmissionsClass,
 	}
-	if err := validateNoOfCylinders(engineReq.NoOfCylinders); err != nil {
-		return err
+}
+
+var (
+	Transmissions     = []string{"Manual", "Automatic", "CVT", "DCT", "Single-speed"}
+	ChargingStandards = []string{"Type 1", "Type 2", "CCS1", "CCS2", "CHAdeMO", "NACS", "GB/T"}
+	EmissionsClasses  = []string{"Euro 1", "Euro 2", "Euro 3", "Euro 4", "Euro 5", "Euro 6", "Euro 6d", "Euro 7", EmissionsZero}
+)
+
+// EmissionsZero is the emissions class of a battery electric engine.
+const EmissionsZero = "ZEV"
+
+// EngineRequestRules checks an engine on its own. Rules that depend on the
+// car's fuel type are in FuelEngineRules.
+func EngineRequestRules() RuleSet[EngineRequest] {
+	return RuleSet[EngineRequest]{
+		atLeastZero("displacement", "Displacement", func(e EngineRequest) float64 { return float64(e.Displacement) }),
+		atLeastZero("noOfCylinders", "Number of cylinders", func(e EngineRequest) float64 { return float64(e.NoOfCylinders) }),
+		{
+			Field:   "noOfCylinders",
+			Name:    "matches_displacement",
+			Message: "Number of cylinders must be 0 exactly when displacement is 0",
+			Valid:   func(e EngineRequest) bool { return (e.Displacement == 0) == (e.NoOfCylinders == 0) },
+		},
+		positive("carRange", "Car range", func(e EngineRequest) float64 { return float64(e.CarRange) }),
+		atLeastZero("horsepower", "Horsepower", func(e EngineRequest) float64 { return float64(e.Horsepower) }),
+		atLeastZero("torque", "Torque", func(e EngineRequest) float64 { return float64(e.Torque) }),
+		optionalOneOf("transmission", "Transmission", Transmissions, func(e EngineRequest) string { return e.Transmission }),
+		atLeastZero("batteryKwh", "Battery capacity", func(e EngineRequest) float64 { return e.BatteryKWh }),
+		optionalOneOf("chargingStandard", "Charging standard", ChargingStandards, func(e EngineRequest) string { return e.ChargingStandard }),
+		{
+			Field:   "chargingStandard",
+			Name:    "requires",
+			Params:  map[string]any{"field": "batteryKwh"},
+			Message: "Charging standard requires a battery capacity",
+			Valid:   func(e EngineRequest) bool { return e.ChargingStandard == "" || e.BatteryKWh > 0 },
+		},
+		optionalOneOf("emissionsClass", "Emissions class", EmissionsClasses, func(e EngineRequest) string { return e.EmissionsClass }),
 	}
-	if err := validateCarRange(engineReq.CarRange); err != nil {
-		return err
+}
+
+func ValidateEngineRequest(engineReq EngineRequest) error {
+	return Check(EngineRequestRules(), engineReq)
+}
+
+// FuelEngine pairs an engine with the fuel type of the car using it.
+type FuelEngine struct {
+	FuelType string
+	Engine   EngineRequest
+}
+
+var combustionFuels = []string{"Petrol", "Diesel", "Hybrid"}
+
+// FuelEngineRules checks that an engine suits the fuel type: electric
+// engines have a battery and no displacement, combustion engines have
+// cylinders, and only hybrids carry both.
+func FuelEngineRules() RuleSet[FuelEngine] {
+	electric := []string{"Electric"}
+	fossil := []string{"Petrol", "Diesel"}
+	batteried := []string{"Electric", "Hybrid"}
+
+	return RuleSet[FuelEngine]{
+		forFuel(electric, Rule[FuelEngine]{
+			Field: "displacement", Name: "eq", Params: map[string]any{"value": 0},
+			Message: "Displacement must be 0 for electric cars",
+			Valid:   func(f FuelEngine) bool { return f.Engine.Displacement == 0 },
+		}),
+		forFuel(electric, Rule[FuelEngine]{
+			Field: "noOfCylinders", Name: "eq", Params: map[string]any{"value": 0},
+			Message: "Number of cylinders must be 0 for electric cars",
+			Valid:   func(f FuelEngine) bool { return f.Engine.NoOfCylinders == 0 },
+		}),
+		forFuel(combustionFuels, Rule[FuelEngine]{
+			Field: "displacement", Name: "gt", Params: map[string]any{"value": 0},
+			Message: "Displacement must be greater than 0 for combustion engines",
+			Valid:   func(f FuelEngine) bool { return f.Engine.Displacement > 0 },
+		}),
+		forFuel(combustionFuels, Rule[FuelEngine]{
+			Field: "noOfCylinders", Name: "gt", Params: map[string]any{"value": 0},
+			Message: "Number of cylinders must be greater than 0 for combustion engines",
+			Valid:   func(f FuelEngine) bool { return f.Engine.NoOfCylinders > 0 },
+		}),
+		forFuel(batteried, Rule[FuelEngine]{
+			Field: "batteryKwh", Name: "gt", Params: map[string]any{"value": 0},
+			Message: "Battery capacity is required for electric and hybrid cars",
+			Valid:   func(f FuelEngine) bool { return f.Engine.BatteryKWh > 0 },
+		}),
+		forFuel(fossil, Rule[FuelEngine]{
+			Field: "batteryKwh", Name: "eq", Params: map[string]any{"value": 0},
+			Message: "Battery capacity must be 0 for petrol and diesel cars",
+			Valid:   func(f FuelEngine) bool { return f.Engine.BatteryKWh == 0 },
+		}),
+		forFuel(electric, Rule[FuelEngine]{
+			Field: "chargingStandard", Name: "required",
+			Message: "Charging standard is required for electric cars",
+			Valid:   func(f FuelEngine) bool { return f.Engine.ChargingStandard != "" },
+		}),
+		forFuel(electric, Rule[FuelEngine]{
+			Field: "emissionsClass", Name: "one_of", Params: map[string]any{"values": []string{EmissionsZero}},
+			Message: "Emissions class must be " + EmissionsZero + " for electric cars",
+			Valid:   func(f FuelEngine) bool { return f.Engine.EmissionsClass == "" || f.Engine.EmissionsClass == EmissionsZero },
+		}),
+		forFuel(combustionFuels, Rule[FuelEngine]{
+			Field: "emissionsClass", Name: "not_one_of", Params: map[string]any{"values": []string{EmissionsZero}},
+			Message: "Emissions class " + EmissionsZero + " is only for electric cars",
+			Valid:   func(f FuelEngine) bool { return f.Engine.EmissionsClass != EmissionsZero },
+		}),
 	}
-	return nil
 }
 
-func validateDisplacement(displacement int64) error {
-	if displacement <= 0 {
-		return errors.New("displacement must be greater than 0")
+// ValidateFuelEngine checks an existing engine against the fuel type of a
+// car that is about to use it. Fields are reported under prefix.
+func ValidateFuelEngine(prefix, fuelType string, engine EngineRequest) error {
+	return Check(Nest(prefix, FuelEngineRules(), func(f FuelEngine) FuelEngine { return f }), FuelEngine{FuelType: fuelType, Engine: engine})
+}
+
+// FuelEngineChangeRules is FuelEngineRules for an engine that was stored as
+// before. Engines saved before the fuel-aware rules existed may not meet
+// them, so a rule is only enforced when the fuel type or the field it checks
+// changes; otherwise every edit of a legacy electric or hybrid car would be
+// rejected until its engine is filled in.
+func FuelEngineChangeRules(before FuelEngine) RuleSet[FuelEngine] {
+	rules := FuelEngineRules()
+	for i, r := range rules {
+		valid := r.Valid
+		rules[i].Valid = func(f FuelEngine) bool {
+			unchanged := f.FuelType == before.FuelType && engineField(f.Engine, r.Field) == engineField(before.Engine, r.Field)
+			return unchanged || valid(f)
+		}
 	}
-	return nil
+	return rules
+}
+
+// ValidateFuelEngineChange is ValidateFuelEngine for an engine that replaces
+// before, which was used with the same or another fuel type.
+func ValidateFuelEngineChange(prefix string, before, after FuelEngine) error {
+	return Check(Nest(prefix, FuelEngineChangeRules(before), func(f FuelEngine) FuelEngine { return f }), after)
 }
 
-func validateNoOfCylinders(noOfCylinders int) error {
-	if noOfCylinders <= 0 {
-		return errors.New("number of cylinders must be greater than 0")
+// engineField returns the value of the engine field named as in the rules.
+func engineField(e EngineRequest, field string) any {
+	switch field {
+	case "displacement":
+		return e.Displacement
+	case "noOfCylinders":
+		return e.NoOfCylinders
+	case "batteryKwh":
+		return e.BatteryKWh
+	case "chargingStandard":
+		return e.ChargingStandard
+	case "emissionsClass":
+		return e.EmissionsClass
 	}
 	return nil
 }
 
-func validateCarRange(carRange int64) error {
-	if carRange <= 0 {
-		return errors.New("car range must be greater than 0")
+// forFuel limits rule to cars of the given fuel types.
+func forFuel(fuelTypes []string, rule Rule[FuelEngine]) Rule[FuelEngine] {
+	params := map[string]any{"fuel_types": fuelTypes}
+	for k, v := range rule.Params {
+		params[k] = v
 	}
-	return nil
-}
\ No newline at end of file
+	valid := rule.Valid
+	rule.Params = params
+	rule.Valid = func(f FuelEngine) bool {
+		for _, t := range fuelTypes {
+			if f.FuelType == t {
+				return valid(f)
+			}
+		}
+		return true
+	}
+	return rule
+}
@@ -0,0 +1,70 @@
+package models
+
+import "errors"
+
+// Sentinel error kinds. Stores and services return *Error values wrapping one
+// of these, so callers can test the kind with errors.Is and handlers can pick
+// the HTTP status without parsing messages.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrBadRequest   = errors.New("bad request")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NotFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func Validation(message string, fields ...FieldError) error {
+	return &Error{Kind: ErrValidation, Message: message, Fields: fields}
+}
+
+func BadRequest(message string) error {
+	return &Error{Kind: ErrBadRequest, Message: message}
+}
+
+func Conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func Unauthorized(message string) error {
+	return &Error{Kind: ErrUnauthorized, Message: message}
+}
+
+func Forbidden(message string) error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func UnsupportedMediaType(message string) error {
+	return &Error{Kind: ErrUnsupportedMediaType, Message: message}
+}
+
+func PreconditionFailed(message string) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: message}
+}
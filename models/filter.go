@@ -0,0 +1,112 @@
+package models
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// CarSortFields are the keys accepted by CarFilter.Sort.
+var CarSortFields = []string{
+	"name", "brand", "year", "price", "created_at", "updated_at",
+	"displacement", "cylinders", "range",
+}
+
+var ErrInvalidCursor = Validation("invalid cursor", FieldError{Field: "cursor", Message: "cursor is malformed or does not match the sort order"})
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// CarFilter describes a car listing query. Nil bounds are not applied.
+type CarFilter struct {
+	Brand    string
+	FuelType string
+	Name     string
+
+	YearMin  *int
+	YearMax  *int
+	PriceMin *float64
+	PriceMax *float64
+
+	DisplacementMin *int64
+	DisplacementMax *int64
+	CylindersMin    *int
+	CylindersMax    *int
+	RangeMin        *int64
+	RangeMax        *int64
+
+	// EngineID lists only the cars using that engine.
+	EngineID string
+	// Statuses lists only cars in one of these statuses.
+	Statuses []string
+
+	// Deleted lists the trash (soft-deleted cars) instead of live cars.
+	Deleted bool
+
+	Sort   []SortField
+	Cursor string
+	Limit  int
+}
+
+type CarPage struct {
+	Cars       []Car  `json:"cars"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func IsCarSortField(field string) bool {
+	for _, f := range CarSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// EngineSortFields are the keys accepted by EngineFilter.Sort.
+var EngineSortFields = []string{"displacement", "cylinders", "range", "cars"}
+
+// EngineFilter describes an engine listing query. Nil bounds are not
+// applied; CarsMin and CarsMax bound the number of live cars using the
+// engine.
+type EngineFilter struct {
+	DisplacementMin *int64
+	DisplacementMax *int64
+	CylindersMin    *int
+	CylindersMax    *int
+	RangeMin        *int64
+	RangeMax        *int64
+	CarsMin         *int
+	CarsMax         *int
+
+	Sort   []SortField
+	Cursor string
+	Limit  int
+}
+
+type EnginePage struct {
+	Engines    []Engine `json:"engines"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// EngineUsage is an engine with the number of cars using it. TrashedCars
+// counts cars in the trash, which keep the engine from being purged.
+type EngineUsage struct {
+	Engine      Engine `json:"engine"`
+	Cars        int    `json:"cars"`
+	TrashedCars int    `json:"trashed_cars"`
+}
+
+type EngineUsagePage struct {
+	Engines    []EngineUsage `json:"engines"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func IsEngineSortField(field string) bool {
+	for _, f := range EngineSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
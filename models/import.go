@@ -0,0 +1,47 @@
+package models
+
+import "github.com/google/uuid"
+
+type ImportMode string
+
+const (
+	// ImportAllOrNothing commits only when every row is valid and saved.
+	ImportAllOrNothing ImportMode = "all_or_nothing"
+	// ImportBestEffort saves the valid rows and reports the rest.
+	ImportBestEffort ImportMode = "best_effort"
+)
+
+const (
+	MaxImportRows   = 50000
+	ImportBatchSize = 500
+)
+
+const (
+	ImportRowCreated = "created"
+	ImportRowInvalid = "invalid"
+	ImportRowFailed  = "failed"
+	ImportRowSkipped = "skipped"
+)
+
+type ImportRowResult struct {
+	Line   int          `json:"line"`
+	Status string       `json:"status"`
+	ID     *uuid.UUID   `json:"id,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// ImportReport is the per-row outcome of an import. Committed is false when
+// nothing was saved.
+type ImportReport struct {
+	Mode      ImportMode        `json:"mode"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+func (m ImportMode) Valid() bool {
+	return m == ImportAllOrNothing || m == ImportBestEffort
+}
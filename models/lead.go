@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lead statuses. won and lost close the lead.
+const (
+	LeadNew       = "new"
+	LeadContacted = "contacted"
+	LeadQualified = "qualified"
+	LeadWon       = "won"
+	LeadLost      = "lost"
+)
+
+var LeadStatuses = []string{LeadNew, LeadContacted, LeadQualified, LeadWon, LeadLost}
+
+var LeadSources = []string{"walk_in", "phone", "website", "referral", "event", "other"}
+
+// Lead is a customer's interest in buying, optionally in one car, worked by
+// the salesperson it is assigned to.
+type Lead struct {
+	ID         uuid.UUID  `json:"id"`
+	CustomerID uuid.UUID  `json:"customer_id"`
+	CarID      *uuid.UUID `json:"car_id,omitempty"`
+	Source     string     `json:"source"`
+	Status     string     `json:"status"`
+	AssignedTo string     `json:"assigned_to,omitempty"`
+	AssignedAt *time.Time `json:"assigned_at,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Version    int        `json:"version"`
+}
+
+type LeadRequest struct {
+	CustomerID string `json:"customer_id"`
+	CarID      string `json:"car_id,omitempty"`
+	Source     string `json:"source"`
+	Status     string `json:"status,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
+
+// LeadFilter selects leads, newest first. AssignedTo lists one
+// salesperson's leads; Unassigned lists the leads nobody has taken.
+type LeadFilter struct {
+	Status     string
+	AssignedTo string
+	Unassigned bool
+	CustomerID string
+	CarID      string
+	Cursor     string
+	Limit      int
+}
+
+type LeadPage struct {
+	Leads      []Lead `json:"leads"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func LeadRequestRules() RuleSet[LeadRequest] {
+	return RuleSet[LeadRequest]{
+		required("customer_id", "Customer ID", func(r LeadRequest) string { return r.CustomerID }),
+		uuidOf("customer_id", "Customer ID", func(r LeadRequest) string { return r.CustomerID }),
+		uuidOf("car_id", "Car ID", func(r LeadRequest) string { return r.CarID }),
+		oneOf("source", "Source", LeadSources, func(r LeadRequest) string { return r.Source }),
+		optionalOneOf("status", "Status", LeadStatuses, func(r LeadRequest) string { return r.Status }),
+	}
+}
+
+func ValidateLeadRequest(req LeadRequest) error {
+	return Check(LeadRequestRules(), req)
+}
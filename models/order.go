@@ -0,0 +1,299 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order statuses. An open order can be edited; completing it sells the car.
+const (
+	OrderOpen      = "open"
+	OrderCompleted = "completed"
+	OrderCancelled = "cancelled"
+)
+
+var OrderStatuses = []string{OrderOpen, OrderCompleted, OrderCancelled}
+
+const (
+	PaymentUnpaid        = "unpaid"
+	PaymentPartiallyPaid = "partially_paid"
+	PaymentPaid          = "paid"
+	PaymentRefunded      = "refunded"
+)
+
+var PaymentStatuses = []string{PaymentUnpaid, PaymentPartiallyPaid, PaymentPaid, PaymentRefunded}
+
+// MaxOrderAdjustments caps the discounts and the taxes of one order.
+const MaxOrderAdjustments = 10
+
+type OrderCustomer struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+type Discount struct {
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+// Tax is a percentage charged on the price after discounts.
+type Tax struct {
+	Name string  `json:"name"`
+	Rate float64 `json:"rate"`
+}
+
+// OrderTotals are derived from the order's price, discounts, taxes and
+// trade-in credit, rounded to cents.
+type OrderTotals struct {
+	Subtotal      float64 `json:"subtotal"`
+	Discounts     float64 `json:"discounts"`
+	Taxable       float64 `json:"taxable"`
+	Taxes         float64 `json:"taxes"`
+	TradeInCredit float64 `json:"trade_in_credit"`
+	Total         float64 `json:"total"`
+}
+
+type Order struct {
+	ID              uuid.UUID     `json:"id"`
+	Number          int64         `json:"number"`
+	CarID           uuid.UUID     `json:"car_id"`
+	CustomerID      *uuid.UUID    `json:"customer_id,omitempty"`
+	Customer        OrderCustomer `json:"customer"`
+	NegotiatedPrice float64       `json:"negotiated_price"`
+	Discounts       []Discount    `json:"discounts"`
+	TradeInCredit   float64       `json:"trade_in_credit"`
+	Taxes           []Tax         `json:"taxes"`
+	Totals          OrderTotals   `json:"totals"`
+	Status          string        `json:"status"`
+	PaymentStatus   string        `json:"payment_status"`
+	Note            string        `json:"note,omitempty"`
+	CreatedBy       string        `json:"created_by"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	CompletedAt     *time.Time    `json:"completed_at,omitempty"`
+	CompletedBy     string        `json:"completed_by,omitempty"`
+	Version         int           `json:"version"`
+}
+
+// OrderRequest describes an order. CustomerID optionally links the order to
+// a customer record; Customer is still required and printed on the invoice.
+type OrderRequest struct {
+	CarID           string        `json:"car_id"`
+	CustomerID      string        `json:"customer_id,omitempty"`
+	Customer        OrderCustomer `json:"customer"`
+	NegotiatedPrice float64       `json:"negotiated_price"`
+	Discounts       []Discount    `json:"discounts"`
+	TradeInCredit   float64       `json:"trade_in_credit"`
+	Taxes           []Tax         `json:"taxes"`
+	PaymentStatus   string        `json:"payment_status,omitempty"`
+	Note            string        `json:"note,omitempty"`
+}
+
+type PaymentRequest struct {
+	PaymentStatus string `json:"payment_status"`
+}
+
+// OrderFilter selects orders, newest first. Empty fields are not applied.
+type OrderFilter struct {
+	Status        string
+	PaymentStatus string
+	CarID         string
+	Cursor        string
+	Limit         int
+}
+
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Amount is what t adds to taxable.
+func (t Tax) Amount(taxable float64) float64 {
+	return roundCents(taxable * t.Rate / 100)
+}
+
+// ComputeTotals prices an order: discounts come off the negotiated price,
+// taxes are charged on the rest and the trade-in credit is deducted last.
+func ComputeTotals(price float64, discounts []Discount, taxes []Tax, tradeIn float64) OrderTotals {
+	t := OrderTotals{Subtotal: roundCents(price), TradeInCredit: roundCents(tradeIn)}
+	for _, d := range discounts {
+		t.Discounts += roundCents(d.Amount)
+	}
+	t.Discounts = roundCents(t.Discounts)
+	t.Taxable = roundCents(t.Subtotal - t.Discounts)
+	for _, tax := range taxes {
+		t.Taxes += tax.Amount(t.Taxable)
+	}
+	t.Taxes = roundCents(t.Taxes)
+	t.Total = roundCents(t.Taxable + t.Taxes - t.TradeInCredit)
+	return t
+}
+
+func (r OrderRequest) Totals() OrderTotals {
+	return ComputeTotals(r.NegotiatedPrice, r.Discounts, r.Taxes, r.TradeInCredit)
+}
+
+func OrderRequestRules() RuleSet[OrderRequest] {
+	return RuleSet[OrderRequest]{
+		required("car_id", "Car ID", func(r OrderRequest) string { return r.CarID }),
+		uuidOf("car_id", "Car ID", func(r OrderRequest) string { return r.CarID }),
+		uuidOf("customer_id", "Customer ID", func(r OrderRequest) string { return r.CustomerID }),
+		required("customer.name", "Customer name", func(r OrderRequest) string { return r.Customer.Name }),
+		email("customer.email", "Customer email", func(r OrderRequest) string { return r.Customer.Email }),
+		positive("negotiated_price", "Negotiated price", func(r OrderRequest) float64 { return r.NegotiatedPrice }),
+		{
+			Field:   "discounts",
+			Name:    "max_items",
+			Params:  map[string]any{"value": MaxOrderAdjustments},
+			Message: fmt.Sprintf("An order can have at most %d discounts", MaxOrderAdjustments),
+			Valid:   func(r OrderRequest) bool { return len(r.Discounts) <= MaxOrderAdjustments },
+		},
+		{
+			Field:   "discounts",
+			Name:    "items",
+			Message: "Each discount needs a description and an amount greater than 0",
+			Valid: func(r OrderRequest) bool {
+				for _, d := range r.Discounts {
+					if strings.TrimSpace(d.Description) == "" || d.Amount <= 0 {
+						return false
+					}
+				}
+				return true
+			},
+		},
+		{
+			Field:   "discounts",
+			Name:    "lte",
+			Params:  map[string]any{"field": "negotiated_price"},
+			Message: "Discounts cannot exceed the negotiated price",
+			Valid:   func(r OrderRequest) bool { return r.Totals().Taxable >= 0 },
+		},
+		{
+			Field:   "taxes",
+			Name:    "max_items",
+			Params:  map[string]any{"value": MaxOrderAdjustments},
+			Message: fmt.Sprintf("An order can have at most %d taxes", MaxOrderAdjustments),
+			Valid:   func(r OrderRequest) bool { return len(r.Taxes) <= MaxOrderAdjustments },
+		},
+		{
+			Field:   "taxes",
+			Name:    "items",
+			Params:  map[string]any{"min": 0, "max": 100},
+			Message: "Each tax needs a name and a rate between 0 and 100",
+			Valid: func(r OrderRequest) bool {
+				for _, t := range r.Taxes {
+					if strings.TrimSpace(t.Name) == "" || t.Rate < 0 || t.Rate > 100 {
+						return false
+					}
+				}
+				return true
+			},
+		},
+		atLeastZero("trade_in_credit", "Trade-in credit", func(r OrderRequest) float64 { return r.TradeInCredit }),
+		{
+			Field:   "trade_in_credit",
+			Name:    "lte",
+			Params:  map[string]any{"field": "total"},
+			Message: "Trade-in credit cannot exceed the order total",
+			Valid:   func(r OrderRequest) bool { return r.Totals().Total >= 0 },
+		},
+		optionalOneOf("payment_status", "Payment status", PaymentStatuses, func(r OrderRequest) string { return r.PaymentStatus }),
+	}
+}
+
+func ValidateOrderRequest(req OrderRequest) error {
+	return Check(OrderRequestRules(), req)
+}
+
+func PaymentRequestRules() RuleSet[PaymentRequest] {
+	return RuleSet[PaymentRequest]{
+		oneOf("payment_status", "Payment status", PaymentStatuses, func(r PaymentRequest) string { return r.PaymentStatus }),
+	}
+}
+
+func ValidatePaymentRequest(req PaymentRequest) error {
+	return Check(PaymentRequestRules(), req)
+}
+
+// InvoiceNumber formats an order number as printed on its invoice.
+func InvoiceNumber(orderNumber int64) string {
+	return fmt.Sprintf("INV-%06d", orderNumber)
+}
+
+type InvoiceVehicle struct {
+	ID       uuid.UUID `json:"id"`
+	Brand    string    `json:"brand"`
+	Name     string    `json:"name"`
+	Year     string    `json:"year"`
+	FuelType string    `json:"fuel_type"`
+}
+
+// InvoiceLine is one printed line; deductions have a negative amount.
+type InvoiceLine struct {
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+// Invoice is the billing document of a completed order.
+type Invoice struct {
+	Number        string         `json:"number"`
+	OrderID       uuid.UUID      `json:"order_id"`
+	IssuedAt      time.Time      `json:"issued_at"`
+	Salesperson   string         `json:"salesperson"`
+	Customer      OrderCustomer  `json:"customer"`
+	Vehicle       InvoiceVehicle `json:"vehicle"`
+	Lines         []InvoiceLine  `json:"lines"`
+	Totals        OrderTotals    `json:"totals"`
+	PaymentStatus string         `json:"payment_status"`
+}
+
+// NewInvoice builds the invoice of a completed order sold with car.
+func NewInvoice(o Order, car Car) Invoice {
+	inv := Invoice{
+		Number:        InvoiceNumber(o.Number),
+		OrderID:       o.ID,
+		Salesperson:   o.CompletedBy,
+		Customer:      o.Customer,
+		Totals:        o.Totals,
+		PaymentStatus: o.PaymentStatus,
+		Vehicle: InvoiceVehicle{
+			ID:       car.ID,
+			Brand:    car.Brand,
+			Name:     car.Name,
+			Year:     car.Year,
+			FuelType: car.FuelType,
+		},
+	}
+	if o.CompletedAt != nil {
+		inv.IssuedAt = *o.CompletedAt
+	}
+
+	inv.Lines = append(inv.Lines, InvoiceLine{
+		Description: fmt.Sprintf("%s %s (%s)", car.Brand, car.Name, car.Year),
+		Amount:      o.Totals.Subtotal,
+	})
+	for _, d := range o.Discounts {
+		inv.Lines = append(inv.Lines, InvoiceLine{Description: "Discount: " + d.Description, Amount: -roundCents(d.Amount)})
+	}
+	for _, t := range o.Taxes {
+		inv.Lines = append(inv.Lines, InvoiceLine{
+			Description: fmt.Sprintf("%s (%s%%)", t.Name, strconv.FormatFloat(t.Rate, 'f', -1, 64)),
+			Amount:      t.Amount(o.Totals.Taxable),
+		})
+	}
+	if o.Totals.TradeInCredit > 0 {
+		inv.Lines = append(inv.Lines, InvoiceLine{Description: "Trade-in credit", Amount: -o.Totals.TradeInCredit})
+	}
+	return inv
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReservationActive    = "active"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+	ReservationFulfilled = "fulfilled"
+)
+
+const (
+	DefaultHoldHours = 48
+	MaxHoldHours     = 14 * 24
+)
+
+// Reservation is a hold on a car for a customer until ExpiresAt. While it
+// is active the car is reserved.
+type Reservation struct {
+	ID              uuid.UUID  `json:"id"`
+	CarID           uuid.UUID  `json:"car_id"`
+	CustomerName    string     `json:"customer_name"`
+	CustomerContact string     `json:"customer_contact,omitempty"`
+	Note            string     `json:"note,omitempty"`
+	Status          string     `json:"status"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	CreatedBy       string     `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	ClosedBy        string     `json:"closed_by,omitempty"`
+}
+
+// ReservationRequest places a hold. HoldHours defaults to DefaultHoldHours.
+type ReservationRequest struct {
+	CustomerName    string `json:"customer_name"`
+	CustomerContact string `json:"customer_contact,omitempty"`
+	Note            string `json:"note,omitempty"`
+	HoldHours       int    `json:"hold_hours,omitempty"`
+}
+
+func ReservationRequestRules() RuleSet[ReservationRequest] {
+	return RuleSet[ReservationRequest]{
+		required("customer_name", "Customer name", func(r ReservationRequest) string { return r.CustomerName }),
+		{
+			Field:   "hold_hours",
+			Name:    "between",
+			Params:  map[string]any{"min": 1, "max": MaxHoldHours},
+			Message: "Hold hours must be between 1 and 336",
+			Valid: func(r ReservationRequest) bool {
+				return r.HoldHours == 0 || r.HoldHours >= 1 && r.HoldHours <= MaxHoldHours
+			},
+		},
+	}
+}
+
+func ValidateReservationRequest(req ReservationRequest) error {
+	return Check(ReservationRequestRules(), req)
+}
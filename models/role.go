@@ -0,0 +1,59 @@
+package models
+
+type Role string
+
+const (
+	RoleViewer           Role = "viewer"
+	RoleSales            Role = "sales"
+	RoleInventoryManager Role = "inventory_manager"
+	RoleAdmin            Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermCarRead     Permission = "car:read"
+	PermCarWrite    Permission = "car:write"
+	PermEngineRead  Permission = "engine:read"
+	PermEngineWrite Permission = "engine:write"
+	PermUserManage  Permission = "user:manage"
+	PermAuditRead   Permission = "audit:read"
+	// PermCarSell covers the sales transitions (reserve, release and sell)
+	// and sales orders.
+	PermCarSell Permission = "car:sell"
+	// PermCustomerManage covers customers, their notes and leads.
+	PermCustomerManage Permission = "customer:manage"
+	// PermSalesManage lets a user oversee other salespeople, e.g. read
+	// their test-drive calendars.
+	PermSalesManage Permission = "sales:manage"
+	// PermOwnAccount lets a user manage their own account, e.g. change
+	// their password. Every role has it.
+	PermOwnAccount Permission = "account:own"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:           {PermCarRead, PermEngineRead, PermOwnAccount},
+	RoleSales:            {PermCarRead, PermEngineRead, PermCarSell, PermCustomerManage, PermOwnAccount},
+	RoleInventoryManager: {PermCarRead, PermCarWrite, PermEngineRead, PermEngineWrite, PermAuditRead, PermCarSell, PermCustomerManage, PermSalesManage, PermOwnAccount},
+}
+
+func (r Role) Valid() bool {
+	if r == RoleAdmin {
+		return true
+	}
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can reports whether the role grants p. Admin is granted everything.
+func (r Role) Can(p Permission) bool {
+	if r == RoleAdmin {
+		return true
+	}
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
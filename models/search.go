@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchTerms     = 8
+)
+
+// CarSearch is a catalogue search. Terms are the normalised words of the
+// query after brand typos have been corrected.
+type CarSearch struct {
+	Terms []string
+	Limit int
+}
+
+type CarSearchResult struct {
+	Car Car `json:"car"`
+	// Rank orders results; higher is a better match.
+	Rank float64 `json:"rank"`
+	// Snippet is the car's searchable text as HTML: the text is escaped and
+	// matched words are wrapped in <mark></mark>.
+	Snippet string `json:"snippet"`
+}
+
+type CarSearchResponse struct {
+	Query string `json:"query"`
+	// CorrectedQuery is set when brand names in the query were corrected,
+	// e.g. "toyta corolla" -> "toyota corolla".
+	CorrectedQuery string            `json:"corrected_query,omitempty"`
+	Results        []CarSearchResult `json:"results"`
+}
+
+// SearchTerms lowercases q and splits it into words of letters and digits,
+// keeping at most MaxSearchTerms.
+func SearchTerms(q string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > MaxSearchTerms {
+		terms = terms[:MaxSearchTerms]
+	}
+	return terms
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Car statuses. A car moves in_transit -> available -> reserved -> sold, and
+// can be withdrawn from sale until it is sold.
+const (
+	StatusInTransit = "in_transit"
+	StatusAvailable = "available"
+	StatusReserved  = "reserved"
+	StatusSold      = "sold"
+	StatusWithdrawn = "withdrawn"
+)
+
+var CarStatuses = []string{StatusInTransit, StatusAvailable, StatusReserved, StatusSold, StatusWithdrawn}
+
+// InitialCarStatuses are the statuses a car can be created with.
+var InitialCarStatuses = []string{StatusInTransit, StatusAvailable}
+
+// Transition is a named move between statuses. Permission is what the
+// caller needs to perform it.
+type Transition struct {
+	Action     string
+	From       []string
+	To         string
+	Permission Permission
+}
+
+// CarTransitions is the car status state machine, keyed by action. Each
+// action outside WorkflowActions has an endpoint POST /cars/{id}/<action>.
+var CarTransitions = map[string]Transition{
+	"receive":   {"receive", []string{StatusInTransit}, StatusAvailable, PermCarWrite},
+	"reserve":   {"reserve", []string{StatusAvailable}, StatusReserved, PermCarSell},
+	"release":   {"release", []string{StatusReserved}, StatusAvailable, PermCarSell},
+	"sell":      {"sell", []string{StatusAvailable, StatusReserved}, StatusSold, PermCarSell},
+	"withdraw":  {"withdraw", []string{StatusInTransit, StatusAvailable, StatusReserved}, StatusWithdrawn, PermCarWrite},
+	"reinstate": {"reinstate", []string{StatusWithdrawn}, StatusAvailable, PermCarWrite},
+}
+
+// WorkflowActions are transitions that only happen as a step of another
+// workflow, so they have no endpoint of their own: a car is reserved by
+// placing a hold on it and sold by completing its order.
+var WorkflowActions = map[string]bool{
+	"reserve": true,
+	"sell":    true,
+}
+
+func (t Transition) Allows(from string) bool {
+	for _, s := range t.From {
+		if s == from {
+			return true
+		}
+	}
+	return false
+}
+
+func IsCarStatus(status string) bool {
+	for _, s := range CarStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionRequest is the optional body of a transition endpoint.
+type TransitionRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// CarStatusChange records one transition: who moved the car and when.
+type CarStatusChange struct {
+	ID        int64     `json:"id"`
+	CarID     uuid.UUID `json:"car_id"`
+	Action    string    `json:"action"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedBy string    `json:"changed_by"`
+	Note      string    `json:"note,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
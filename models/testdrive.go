@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TestDriveScheduled = "scheduled"
+	TestDriveCancelled = "cancelled"
+)
+
+var TestDriveStatuses = []string{TestDriveScheduled, TestDriveCancelled}
+
+const (
+	MinTestDriveDuration = 15 * time.Minute
+	MaxTestDriveDuration = 4 * time.Hour
+)
+
+// TestDrive is a booked slot in which a salesperson takes a customer out in
+// a car. Scheduled drives never overlap for the same car or salesperson.
+type TestDrive struct {
+	ID              uuid.UUID  `json:"id"`
+	CarID           uuid.UUID  `json:"car_id"`
+	Car             string     `json:"car"`
+	CustomerID      *uuid.UUID `json:"customer_id,omitempty"`
+	CustomerName    string     `json:"customer_name"`
+	CustomerContact string     `json:"customer_contact,omitempty"`
+	Salesperson     string     `json:"salesperson"`
+	StartsAt        time.Time  `json:"starts_at"`
+	EndsAt          time.Time  `json:"ends_at"`
+	Status          string     `json:"status"`
+	Note            string     `json:"note,omitempty"`
+	CreatedBy       string     `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+	CancelledBy     string     `json:"cancelled_by,omitempty"`
+	Version         int        `json:"version"`
+}
+
+// TestDriveRequest books a test drive. Salesperson defaults to the caller.
+type TestDriveRequest struct {
+	CustomerID      string    `json:"customer_id,omitempty"`
+	CustomerName    string    `json:"customer_name"`
+	CustomerContact string    `json:"customer_contact,omitempty"`
+	Salesperson     string    `json:"salesperson,omitempty"`
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+	Note            string    `json:"note,omitempty"`
+}
+
+// RescheduleRequest moves a test drive to another slot. Leaving salesperson
+// empty keeps the current one.
+type RescheduleRequest struct {
+	Salesperson string    `json:"salesperson,omitempty"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+}
+
+// TestDriveFilter selects the test drives that overlap [From, To), earliest
+// first. A zero To leaves the window open-ended.
+type TestDriveFilter struct {
+	CarID       string
+	Salesperson string
+	Status      string
+	From        time.Time
+	To          time.Time
+}
+
+func TestDriveRequestRules() RuleSet[TestDriveRequest] {
+	return append(RuleSet[TestDriveRequest]{
+		uuidOf("customer_id", "Customer ID", func(r TestDriveRequest) string { return r.CustomerID }),
+		required("customer_name", "Customer name", func(r TestDriveRequest) string { return r.CustomerName }),
+	}, slotRules(func(r TestDriveRequest) (time.Time, time.Time) { return r.StartsAt, r.EndsAt })...)
+}
+
+func ValidateTestDriveRequest(req TestDriveRequest) error {
+	return Check(TestDriveRequestRules(), req)
+}
+
+func RescheduleRequestRules() RuleSet[RescheduleRequest] {
+	return slotRules(func(r RescheduleRequest) (time.Time, time.Time) { return r.StartsAt, r.EndsAt })
+}
+
+func ValidateRescheduleRequest(req RescheduleRequest) error {
+	return Check(RescheduleRequestRules(), req)
+}
+
+// slotRules checks that a slot starts in the future and lasts between
+// MinTestDriveDuration and MaxTestDriveDuration.
+func slotRules[T any](get func(T) (time.Time, time.Time)) RuleSet[T] {
+	return RuleSet[T]{
+		{
+			Field:   "starts_at",
+			Name:    "future",
+			Message: "Start time must be in the future",
+			Valid: func(v T) bool {
+				start, _ := get(v)
+				return start.After(time.Now())
+			},
+		},
+		{
+			Field:   "ends_at",
+			Name:    "duration",
+			Params:  map[string]any{"min_minutes": int(MinTestDriveDuration.Minutes()), "max_minutes": int(MaxTestDriveDuration.Minutes())},
+			Message: "Test drive must last between 15 minutes and 4 hours",
+			Valid: func(v T) bool {
+				start, end := get(v)
+				d := end.Sub(start)
+				return d >= MinTestDriveDuration && d <= MaxTestDriveDuration
+			},
+		},
+	}
+}
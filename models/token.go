@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken is the server-side record of an issued refresh token. Only
+// the SHA-256 hash of the token is kept.
+type RefreshToken struct {
+	ID        uuid.UUID
+	SessionID uuid.UUID
+	Hash      string
+	ExpiresAt time.Time
+}
+
+var (
+	ErrInvalidRefreshToken = Unauthorized("invalid or expired refresh token")
+	ErrRefreshTokenReused  = Unauthorized("refresh token reuse detected; session revoked")
+)
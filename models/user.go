@@ -0,0 +1,101 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         Role      `json:"role"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     Role   `json:"role"`
+}
+
+type ChangeRoleRequest struct {
+	Role Role `json:"role"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+var (
+	ErrInvalidCredentials = Unauthorized("invalid username or password")
+	ErrUserExists         = Conflict("username already taken")
+	ErrUserNotFound       = NotFound("user not found")
+)
+
+const (
+	MinPasswordLength = 8
+	// bcrypt mengabaikan byte setelah 72
+	MaxPasswordLength = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{3,64}$`)
+
+var Roles = []string{string(RoleViewer), string(RoleSales), string(RoleInventoryManager), string(RoleAdmin)}
+
+func passwordRules[T any](field string, get func(T) string) RuleSet[T] {
+	return RuleSet[T]{
+		{
+			Field:   field,
+			Name:    "min_length",
+			Params:  map[string]any{"value": MinPasswordLength},
+			Message: "Password must be at least 8 characters",
+			Valid:   func(v T) bool { return len(get(v)) >= MinPasswordLength },
+		},
+		{
+			Field:   field,
+			Name:    "max_length",
+			Params:  map[string]any{"value": MaxPasswordLength},
+			Message: "Password must be at most 72 bytes",
+			Valid:   func(v T) bool { return len(get(v)) <= MaxPasswordLength },
+		},
+	}
+}
+
+func RegisterRequestRules() RuleSet[RegisterRequest] {
+	rules := RuleSet[RegisterRequest]{
+		{
+			Field:   "username",
+			Name:    "pattern",
+			Params:  map[string]any{"value": usernamePattern.String()},
+			Message: "Username must be 3-64 characters of letters, digits, '.', '_' or '-'",
+			Valid:   func(r RegisterRequest) bool { return usernamePattern.MatchString(r.Username) },
+		},
+		oneOf("role", "Role", Roles, func(r RegisterRequest) string { return string(r.Role) }),
+	}
+	return append(rules, passwordRules("password", func(r RegisterRequest) string { return r.Password })...)
+}
+
+func ChangePasswordRequestRules() RuleSet[ChangePasswordRequest] {
+	return passwordRules("newPassword", func(r ChangePasswordRequest) string { return r.NewPassword })
+}
+
+func ValidateRegisterRequest(req RegisterRequest) error {
+	return Check(RegisterRequestRules(), req)
+}
+
+func ValidateChangePasswordRequest(req ChangePasswordRequest) error {
+	return Check(ChangePasswordRequestRules(), req)
+}
+
+func ValidateRole(role Role) error {
+	rules := RuleSet[ChangeRoleRequest]{
+		oneOf("role", "Role", Roles, func(r ChangeRoleRequest) string { return string(r.Role) }),
+	}
+	return Check(rules, ChangeRoleRequest{Role: role})
+}
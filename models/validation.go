@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Rule is one check on one field of T. Params carries the rule's arguments
+// (bounds, allowed values) so the rule can be described to clients.
+type Rule[T any] struct {
+	Field   string
+	Name    string
+	Params  map[string]any
+	Message string
+	Valid   func(T) bool
+}
+
+// RuleDescription is the machine-readable form of a Rule.
+type RuleDescription struct {
+	Field   string         `json:"field"`
+	Rule    string         `json:"rule"`
+	Params  map[string]any `json:"params,omitempty"`
+	Message string         `json:"message"`
+}
+
+type RuleSet[T any] []Rule[T]
+
+// Validate runs every rule and returns all violations. Rules on the same
+// field run in order and stop at the first failure, so "year is required"
+// is not followed by "year must be a number".
+func (rs RuleSet[T]) Validate(v T) []FieldError {
+	var (
+		errs   []FieldError
+		failed = map[string]bool{}
+	)
+	for _, r := range rs {
+		if failed[r.Field] {
+			continue
+		}
+		if !r.Valid(v) {
+			failed[r.Field] = true
+			errs = append(errs, FieldError{Field: r.Field, Rule: r.Name, Message: r.Message})
+		}
+	}
+	return errs
+}
+
+func (rs RuleSet[T]) Describe() []RuleDescription {
+	out := make([]RuleDescription, len(rs))
+	for i, r := range rs {
+		out[i] = RuleDescription{Field: r.Field, Rule: r.Name, Params: r.Params, Message: r.Message}
+	}
+	return out
+}
+
+// Nest lifts the rules of a nested value U into T, prefixing field paths.
+func Nest[T, U any](prefix string, rules RuleSet[U], get func(T) U) RuleSet[T] {
+	out := make(RuleSet[T], len(rules))
+	for i, r := range rules {
+		valid := r.Valid
+		out[i] = Rule[T]{
+			Field:   prefix + "." + r.Field,
+			Name:    r.Name,
+			Params:  r.Params,
+			Message: r.Message,
+			Valid:   func(v T) bool { return valid(get(v)) },
+		}
+	}
+	return out
+}
+
+// Check validates v against rules and wraps any violations in a single
+// Validation error.
+func Check[T any](rules RuleSet[T], v T) error {
+	errs := rules.Validate(v)
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) == 1 {
+		return Validation(errs[0].Message, errs...)
+	}
+	return Validation(fmt.Sprintf("request has %d invalid fields", len(errs)), errs...)
+}
+
+func required[T any](field, label string, get func(T) string) Rule[T] {
+	return Rule[T]{
+		Field:   field,
+		Name:    "required",
+		Message: label + " cannot be empty",
+		Valid:   func(v T) bool { return strings.TrimSpace(get(v)) != "" },
+	}
+}
+
+func oneOf[T any](field, label string, allowed []string, get func(T) string) Rule[T] {
+	return Rule[T]{
+		Field:   field,
+		Name:    "one_of",
+		Params:  map[string]any{"values": allowed},
+		Message: label + " must be one of the following: " + strings.Join(allowed, ", "),
+		Valid: func(v T) bool {
+			got := get(v)
+			for _, a := range allowed {
+				if got == a {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// optionalOneOf is oneOf for a field that may be left empty.
+func optionalOneOf[T any](field, label string, allowed []string, get func(T) string) Rule[T] {
+	rule := oneOf(field, label, allowed, get)
+	valid := rule.Valid
+	rule.Valid = func(v T) bool { return get(v) == "" || valid(v) }
+	return rule
+}
+
+// email accepts an empty value or one that looks like an address; delivery
+// is the only real check.
+func email[T any](field, label string, get func(T) string) Rule[T] {
+	return Rule[T]{
+		Field:   field,
+		Name:    "email",
+		Message: label + " must be a valid email address",
+		Valid: func(v T) bool {
+			e := get(v)
+			at := strings.Index(e, "@")
+			return e == "" || at > 0 && at == strings.LastIndex(e, "@") && at < len(e)-1
+		},
+	}
+}
+
+// uuidOf accepts an empty value or a UUID.
+func uuidOf[T any](field, label string, get func(T) string) Rule[T] {
+	return Rule[T]{
+		Field:   field,
+		Name:    "uuid",
+		Message: label + " must be a valid UUID",
+		Valid: func(v T) bool {
+			id := get(v)
+			if id == "" {
+				return true
+			}
+			_, err := uuid.Parse(id)
+			return err == nil
+		},
+	}
+}
+
+func atLeastZero[T any](field, label string, get func(T) float64) Rule[T] {
+	return Rule[T]{
+		Field:   field,
+		Name:    "gte",
+		Params:  map[string]any{"value": 0},
+		Message: label + " cannot be negative",
+		Valid:   func(v T) bool { return get(v) >= 0 },
+	}
+}
+
+func positive[T any](field, label string, get func(T) float64) Rule[T] {
+	return Rule[T]{
+		Field:   field,
+		Name:    "gt",
+		Params:  map[string]any{"value": 0},
+		Message: label + " must be greater than 0",
+		Valid:   func(v T) bool { return get(v) > 0 },
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+// VersionMatch is the set of versions an If-Match header accepts. A nil
+// VersionMatch means the request is unconditional (no header, or "*"); a
+// non-nil empty one matches nothing, e.g. when only weak ETags were sent.
+type VersionMatch []int
+
+func (m VersionMatch) Allows(version int) bool {
+	if m == nil {
+		return true
+	}
+	for _, v := range m {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckVersion returns a PreconditionFailed error naming what when version is
+// not accepted by m.
+func (m VersionMatch) CheckVersion(what string, version int) error {
+	if m.Allows(version) {
+		return nil
+	}
+	return PreconditionFailed(what + " has been modified; fetch it again and retry")
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// SystemActor is recorded when a change is not made on behalf of a user,
+// such as background jobs and CLI commands.
+const SystemActor = "system"
+
+type AuditService struct {
+	store store.AuditStoreInterface
+}
+
+func NewAuditService(store store.AuditStoreInterface) *AuditService {
+	return &AuditService{store: store}
+}
+
+// Record writes an audit entry for one change. before is nil for creations
+// and after is nil when there is nothing left to show. Call it with the
+// context of the transaction that made the change.
+func (s *AuditService) Record(ctx context.Context, action, entityType string, entityID uuid.UUID, before, after any) error {
+	tracer := otel.Tracer("AuditService")
+	ctx, span := tracer.Start(ctx, "Record-Service")
+	defer span.End()
+
+	actor, ok := middleware.UsernameFromContext(ctx)
+	if !ok {
+		actor = SystemActor
+	}
+
+	entry := models.AuditEntry{
+		Actor:      actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  middleware.RequestIDFromContext(ctx),
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = snapshot(after); err != nil {
+		return err
+	}
+	if entry.Changes, err = diff(entry.Before, entry.After); err != nil {
+		return err
+	}
+
+	return s.store.Record(ctx, entry)
+}
+
+func (s *AuditService) ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+	tracer := otel.Tracer("AuditService")
+	ctx, span := tracer.Start(ctx, "ListAudit-Service")
+	defer span.End()
+
+	return s.store.ListAudit(ctx, filter)
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// diff compares two JSON objects and returns the changed leaves keyed by
+// dotted path, e.g. "engine.displacement".
+func diff(before, after json.RawMessage) (map[string]models.Change, error) {
+	var b, a map[string]any
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, err
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := map[string]models.Change{}
+	diffObjects("", b, a, changes)
+	return changes, nil
+}
+
+func diffObjects(prefix string, before, after map[string]any, changes map[string]models.Change) {
+	seen := map[string]bool{}
+	for key := range before {
+		seen[key] = true
+	}
+	for key := range after {
+		seen[key] = true
+	}
+
+	for key := range seen {
+		from, to := before[key], after[key]
+		fromObj, fromIsObj := from.(map[string]any)
+		toObj, toIsObj := to.(map[string]any)
+		switch {
+		case fromIsObj && toIsObj,
+			fromIsObj && to == nil,
+			from == nil && toIsObj:
+			diffObjects(prefix+key+".", fromObj, toObj, changes)
+		case !reflect.DeepEqual(from, to):
+			changes[prefix+key] = models.Change{From: from, To: to}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 7 * 24 * time.Hour
+)
+
+// TokenSigner signs access token claims (see keyset.KeySet.Sign).
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+}
+
+type AuthService struct {
+	users      service.UserServiceInterface
+	sessions   store.SessionStoreInterface
+	signer     TokenSigner
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthService(users service.UserServiceInterface, sessions store.SessionStoreInterface, signer TokenSigner, accessTTL, refreshTTL time.Duration) *AuthService {
+	return &AuthService{users: users, sessions: sessions, signer: signer, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+func (s *AuthService) Login(ctx context.Context, cred models.Credential) (*models.TokenPair, error) {
+	tracer := otel.Tracer("AuthService")
+	ctx, span := tracer.Start(ctx, "Login-Service")
+	defer span.End()
+
+	user, err := s.users.Authenticate(ctx, cred)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, raw, err := s.newRefreshToken(uuid.New())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.CreateSession(ctx, user.ID, refresh); err != nil {
+		return nil, err
+	}
+	return s.tokenPair(*user, refresh.SessionID, raw)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// token is consumed, so every refresh token works exactly once.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	tracer := otel.Tracer("AuthService")
+	ctx, span := tracer.Start(ctx, "Refresh-Service")
+	defer span.End()
+
+	if refreshToken == "" {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	// Session ID mengikuti token lama; store yang mengisinya saat rotasi.
+	next, raw, err := s.newRefreshToken(uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, username, err := s.sessions.RotateRefreshToken(ctx, hashToken(refreshToken), next)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	return s.tokenPair(*user, sessionID, raw)
+}
+
+// Logout revokes the caller's session and the access token it presented.
+func (s *AuthService) Logout(ctx context.Context, jti, sessionID string, expiresAt time.Time) error {
+	tracer := otel.Tracer("AuthService")
+	ctx, span := tracer.Start(ctx, "Logout-Service")
+	defer span.End()
+
+	if err := s.sessions.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.sessions.RevokeToken(ctx, jti, expiresAt)
+}
+
+// IsRevoked implements middleware.RevocationChecker.
+func (s *AuthService) IsRevoked(ctx context.Context, jti, sessionID string) (bool, error) {
+	return s.sessions.IsRevoked(ctx, jti, sessionID)
+}
+
+// RunCleanup periodically deletes expired refresh tokens and revocation
+// entries until ctx is cancelled.
+func (s *AuthService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.sessions.DeleteExpired(ctx, now); err != nil {
+				log.Println("Error cleaning up expired tokens:", err)
+			}
+		}
+	}
+}
+
+func (s *AuthService) tokenPair(user models.User, sessionID uuid.UUID, refreshToken string) (*models.TokenPair, error) {
+	now := time.Now()
+	claims := &middleware.Claims{
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   user.Username,
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	signed, err := s.signer.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  signed,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func (s *AuthService) newRefreshToken(sessionID uuid.UUID) (models.RefreshToken, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return models.RefreshToken{}, "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	return models.RefreshToken{
+		ID:        uuid.New(),
+		SessionID: sessionID,
+		Hash:      hashToken(raw),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}, raw, nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package car
+
+import (
+	"context"
+	"errors"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// errBatchAborted rolls the batch transaction back after the first failure
+// when the caller did not ask to continue on error.
+var errBatchAborted = errors.New("batch aborted")
+
+// BatchCars applies every operation in one transaction through the same
+// create, update and delete paths as the single-car endpoints. Each operation
+// runs in its own savepoint, so with ContinueOnError a failure undoes only
+// that operation; otherwise the first failure rolls the whole batch back.
+func (s *CarService) BatchCars(ctx context.Context, req models.BatchRequest) (*models.BatchReport, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "BatchCars-Service")
+	defer span.End()
+
+	if err := models.ValidateBatchRequest(req); err != nil {
+		return nil, err
+	}
+
+	report := &models.BatchReport{Total: len(req.Operations), Results: make([]models.BatchResult, len(req.Operations))}
+	for i, op := range req.Operations {
+		report.Results[i] = models.BatchResult{Index: i, Op: op.Op, Status: models.BatchOpSkipped}
+	}
+
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		for i, op := range req.Operations {
+			result := &report.Results[i]
+			car, err := s.applyOperation(ctx, op)
+			if err != nil {
+				result.Status = models.BatchOpFailed
+				setResultError(result, err)
+				report.Failed++
+				if !req.ContinueOnError {
+					return errBatchAborted
+				}
+				continue
+			}
+			id := car.ID
+			result.Status, result.ID, result.Car = models.BatchOpSucceeded, &id, car
+			report.Succeeded++
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchAborted) {
+		for i := range report.Results {
+			if r := &report.Results[i]; r.Status == models.BatchOpSucceeded {
+				r.Status, r.Car = models.BatchOpRolledBack, nil
+			}
+		}
+		report.Succeeded = 0
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.Committed = true
+	return report, nil
+}
+
+func (s *CarService) applyOperation(ctx context.Context, op models.BatchOperation) (*models.Car, error) {
+	var match models.VersionMatch
+	if op.Version != nil {
+		match = models.VersionMatch{*op.Version}
+	}
+
+	if op.Op != models.BatchCreate {
+		if _, err := uuid.Parse(op.ID); err != nil {
+			return nil, models.Validation("id must be a valid UUID", models.FieldError{Field: "id", Rule: "uuid", Message: "must be a valid UUID"})
+		}
+	}
+	if (op.Op == models.BatchCreate || op.Op == models.BatchUpdate) && op.Car == nil {
+		return nil, models.Validation("car is required", models.FieldError{Field: "car", Rule: "required", Message: "is required for " + op.Op})
+	}
+
+	switch op.Op {
+	case models.BatchCreate:
+		return s.CreateCar(ctx, op.Car)
+	case models.BatchUpdate:
+		return s.UpdateCar(ctx, op.ID, op.Car, match)
+	case models.BatchDelete:
+		return s.DeleteCar(ctx, op.ID, match)
+	default:
+		return nil, models.Validation("unknown operation", models.FieldError{Field: "op", Rule: "oneof", Message: "must be one of create, update, delete"})
+	}
+}
+
+// setResultError copies a domain error into the result; anything else is
+// reported generically so database details stay out of the report.
+func setResultError(result *models.BatchResult, err error) {
+	var typed *models.Error
+	if errors.As(err, &typed) {
+		result.Error, result.Fields = typed.Message, typed.Fields
+		return
+	}
+	result.Error = "operation could not be applied"
+}
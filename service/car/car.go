@@ -2,18 +2,30 @@ package car
 
 import (
 	"context"
+	"log"
+	"time"
 
+	"github.com/KRAZYFLASH/carZone/jsonpatch"
+	"github.com/KRAZYFLASH/carZone/middleware"
 	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
 	"github.com/KRAZYFLASH/carZone/store"
 	"go.opentelemetry.io/otel"
 )
 
+var (
+	errCarSold     = models.Conflict("car has been sold and can no longer be changed")
+	errCarReserved = models.Conflict("car is reserved; release the reservation before deleting it")
+)
+
 type CarService struct {
 	store store.CarStoreInterface
+	tx    store.TransactorInterface
+	audit service.AuditRecorder
 }
 
-func NewCarService(store store.CarStoreInterface) *CarService {
-	return &CarService{store: store}
+func NewCarService(store store.CarStoreInterface, tx store.TransactorInterface, audit service.AuditRecorder) *CarService {
+	return &CarService{store: store, tx: tx, audit: audit}
 }
 
 func (s *CarService) GetCarById(ctx context.Context, id string) (*models.Car, error) {
@@ -28,19 +40,28 @@ func (s *CarService) GetCarById(ctx context.Context, id string) (*models.Car, er
 	return &car, nil
 }
 
-func (s *CarService) GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error) {
+func (s *CarService) ListCars(ctx context.Context, filter models.CarFilter) (models.CarPage, error) {
 	tracer := otel.Tracer("CarService")
-	ctx, span := tracer.Start(ctx, "GetCarByBrand-Service")
+	ctx, span := tracer.Start(ctx, "ListCars-Service")
 	defer span.End()
-	
-	cars, err := s.store.GetCarByBrand(ctx, brand, isEngine)
+
+	page, err := s.store.ListCars(ctx, filter)
 	if err != nil {
-		return nil, err
+		return models.CarPage{}, err
 	}
 
-	return cars, nil
+	return page, nil
 }
 
+// ExportCars streams every car matching filter to fn without loading the
+// whole result into memory.
+func (s *CarService) ExportCars(ctx context.Context, filter models.CarFilter, fn func(models.Car) error) error {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "ExportCars-Service")
+	defer span.End()
+
+	return s.store.StreamCars(ctx, filter, fn)
+}
 
 func (s *CarService) CreateCar(ctx context.Context, carReq *models.CarRequest) (*models.Car, error) {
 	tracer := otel.Tracer("CarService")
@@ -51,37 +72,189 @@ func (s *CarService) CreateCar(ctx context.Context, carReq *models.CarRequest) (
 		return nil, err
 	}
 
-	createdCar, err := s.store.CreateCar(ctx, carReq)
+	var createdCar models.Car
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if createdCar, err = s.store.CreateCar(ctx, carReq); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditCreated, models.EntityCar, createdCar.ID, nil, createdCar)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &createdCar, nil
 }
 
-func (s *CarService) UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (*models.Car, error) {
+func (s *CarService) UpdateCar(ctx context.Context, id string, carReq *models.CarRequest, match models.VersionMatch) (*models.Car, error) {
 	tracer := otel.Tracer("CarService")
 	ctx, span := tracer.Start(ctx, "UpdateCar-Service")
 	defer span.End()
 
-	if err := models.ValidateRequest(*carReq); err != nil {
+	return s.updateCar(ctx, id, carReq, match, models.AuditUpdated)
+}
+
+// updateCar validates and saves carReq and records it in the audit log as
+// action. Sold cars are final and cannot be changed.
+func (s *CarService) updateCar(ctx context.Context, id string, carReq *models.CarRequest, match models.VersionMatch, action string) (*models.Car, error) {
+	var updatedCar models.Car
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetCarForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.Status == models.StatusSold {
+			return errCarSold
+		}
+		if err := models.ValidateUpdateRequest(*carReq, before); err != nil {
+			return err
+		}
+		if updatedCar, err = s.store.UpdateCar(ctx, id, carReq, match); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, action, models.EntityCar, updatedCar.ID, before, updatedCar)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return &updatedCar, nil
+}
+
+// PatchCar applies a merge patch or JSON Patch to the current car and saves
+// the result. Validation runs on the merged request, so clients only send the
+// fields they change.
+func (s *CarService) PatchCar(ctx context.Context, id string, patch jsonpatch.Patch, match models.VersionMatch) (*models.Car, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "PatchCar-Service")
+	defer span.End()
 
-	updatedCar, err := s.store.UpdateCar(ctx, id, carReq)
+	current, err := s.store.GetCarById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return &updatedCar, nil
+	if err := match.CheckVersion("car", current.Version); err != nil {
+		return nil, err
+	}
+
+	var carReq models.CarRequest
+	if err := service.ApplyPatch(patch, current.Request(), &carReq); err != nil {
+		return nil, err
+	}
+
+	// Save against the version the patch was applied to, so a concurrent
+	// write in between is reported instead of silently overwritten.
+	return s.UpdateCar(ctx, id, &carReq, models.VersionMatch{current.Version})
 }
 
-func (s *CarService) DeleteCar(ctx context.Context, id string) (*models.Car, error) {
+func (s *CarService) DeleteCar(ctx context.Context, id string, match models.VersionMatch) (*models.Car, error) {
 	tracer := otel.Tracer("CarService")
 	ctx, span := tracer.Start(ctx, "DeleteCar-Service")
 	defer span.End()
+
+	deletedBy, _ := middleware.UsernameFromContext(ctx)
 	
-	deletedCar, err := s.store.DeleteCar(ctx, id)
+	var deletedCar models.Car
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetCarForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		switch before.Status {
+		case models.StatusReserved:
+			return errCarReserved
+		case models.StatusSold:
+			return errCarSold
+		}
+		if deletedCar, err = s.store.DeleteCar(ctx, id, deletedBy, match); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditDeleted, models.EntityCar, deletedCar.ID, before, deletedCar)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &deletedCar, nil
-}
\ No newline at end of file
+}
+
+func (s *CarService) RestoreCar(ctx context.Context, id string) (*models.Car, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "RestoreCar-Service")
+	defer span.End()
+
+	var restoredCar models.Car
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if restoredCar, err = s.store.RestoreCar(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditRestored, models.EntityCar, restoredCar.ID, nil, restoredCar)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &restoredCar, nil
+}
+
+func (s *CarService) ListCarHistory(ctx context.Context, id string) ([]models.CarRevision, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "ListCarHistory-Service")
+	defer span.End()
+
+	return s.store.ListCarHistory(ctx, id)
+}
+
+// GetCarAsOf returns the car as it was stored at t.
+func (s *CarService) GetCarAsOf(ctx context.Context, id string, t time.Time) (*models.Car, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "GetCarAsOf-Service")
+	defer span.End()
+
+	rev, err := s.store.GetCarAsOf(ctx, id, t)
+	if err != nil {
+		return nil, err
+	}
+	return &rev.Car, nil
+}
+
+// RevertCar writes the fields of a stored version back as a new version.
+// History is never rewritten; the revert itself becomes the latest version.
+func (s *CarService) RevertCar(ctx context.Context, id string, version int, match models.VersionMatch) (*models.Car, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "RevertCar-Service")
+	defer span.End()
+
+	if err := models.ValidateRevertRequest(models.RevertRequest{Version: version}); err != nil {
+		return nil, err
+	}
+
+	rev, err := s.store.GetCarRevision(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	carReq := rev.Car.Request()
+	return s.updateCar(ctx, id, &carReq, match, models.AuditReverted)
+}
+
+// RunPurge permanently removes cars that have been in the trash longer than
+// retention, checking every interval until ctx is cancelled.
+func (s *CarService) RunPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := s.store.PurgeDeleted(ctx, now.Add(-retention))
+			if err != nil {
+				log.Println("Error purging deleted cars:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Purged %d deleted car(s)", n)
+			}
+		}
+	}
+}
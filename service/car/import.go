@@ -0,0 +1,186 @@
+package car
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/KRAZYFLASH/carZone/carfile"
+	"github.com/KRAZYFLASH/carZone/models"
+	"go.opentelemetry.io/otel"
+)
+
+// errImportFailed rolls back an all-or-nothing import once some rows could
+// not be saved; the report says which.
+var errImportFailed = errors.New("import rows failed")
+
+// ImportCars validates every record and inserts the valid ones in batches of
+// models.ImportBatchSize. A batch that fails is retried row by row, so the
+// report names the rows that cannot be saved. In all-or-nothing mode one
+// invalid or failed row saves nothing; in best-effort mode each batch
+// commits on its own.
+func (s *CarService) ImportCars(ctx context.Context, records []carfile.Record, mode models.ImportMode) (*models.ImportReport, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "ImportCars-Service")
+	defer span.End()
+
+	if !mode.Valid() {
+		return nil, models.Validation("mode must be all_or_nothing or best_effort", models.FieldError{Field: "mode", Message: "must be all_or_nothing or best_effort"})
+	}
+	if len(records) > models.MaxImportRows {
+		message := "at most " + strconv.Itoa(models.MaxImportRows) + " rows are allowed"
+		return nil, models.Validation("import has too many rows", models.FieldError{Field: "rows", Rule: "max", Message: message})
+	}
+
+	report := &models.ImportReport{Mode: mode, Total: len(records), Rows: make([]models.ImportRowResult, len(records))}
+
+	var valid []int
+	for i, rec := range records {
+		row := &report.Rows[i]
+		row.Line = rec.Line
+
+		err := rec.Err
+		if err == nil {
+			err = models.ValidateRequest(rec.Car)
+		}
+		if err != nil {
+			row.Status = models.ImportRowInvalid
+			setRowError(row, err)
+			report.Failed++
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	if mode == models.ImportAllOrNothing && report.Failed > 0 {
+		for _, i := range valid {
+			report.Rows[i].Status = models.ImportRowSkipped
+		}
+		return report, nil
+	}
+
+	batches := make([][]int, 0, len(valid)/models.ImportBatchSize+1)
+	for start := 0; start < len(valid); start += models.ImportBatchSize {
+		end := min(start+models.ImportBatchSize, len(valid))
+		batches = append(batches, valid[start:end])
+	}
+
+	if mode == models.ImportAllOrNothing {
+		// Batches run in savepoints of one transaction, which is rolled back
+		// once the retries have found every row that fails.
+		var saved [][]int
+		var created [][]models.Car
+		err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+			for _, batch := range batches {
+				rows, cars := s.saveBatch(ctx, report, records, batch)
+				saved, created = append(saved, rows), append(created, cars)
+			}
+			if report.Failed > 0 {
+				return errImportFailed
+			}
+			return nil
+		})
+		if errors.Is(err, errImportFailed) {
+			for _, i := range valid {
+				if report.Rows[i].Status == "" {
+					report.Rows[i].Status = models.ImportRowSkipped
+				}
+			}
+			return report, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for b, rows := range saved {
+			markCreated(report, rows, created[b])
+		}
+		report.Committed = true
+		return report, nil
+	}
+
+	for _, batch := range batches {
+		rows, cars := s.saveBatch(ctx, report, records, batch)
+		markCreated(report, rows, cars)
+	}
+	report.Committed = report.Created > 0
+	return report, nil
+}
+
+// saveBatch commits the batch, or when that fails retries its rows one by
+// one so only the rows that cannot be saved, such as one with an unknown
+// engine_id, are marked failed in report. It returns the rows saved and
+// their cars.
+func (s *CarService) saveBatch(ctx context.Context, report *models.ImportReport, records []carfile.Record, batch []int) ([]int, []models.Car) {
+	cars, err := s.commitBatch(ctx, records, batch)
+	if err == nil {
+		return batch, cars
+	}
+	log.Printf("import batch failed, retrying row by row: %v", err)
+
+	var (
+		saved   []int
+		created []models.Car
+	)
+	for _, i := range batch {
+		cars, err := s.commitBatch(ctx, records, []int{i})
+		if err != nil {
+			report.Rows[i].Status = models.ImportRowFailed
+			setRowError(&report.Rows[i], err)
+			report.Failed++
+			continue
+		}
+		saved, created = append(saved, i), append(created, cars...)
+	}
+	return saved, created
+}
+
+// commitBatch inserts the batch in its own transaction, or in a savepoint
+// when ctx already carries one.
+func (s *CarService) commitBatch(ctx context.Context, records []carfile.Record, batch []int) ([]models.Car, error) {
+	var cars []models.Car
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		cars, err = s.insertBatch(ctx, records, batch)
+		return err
+	})
+	return cars, err
+}
+
+func (s *CarService) insertBatch(ctx context.Context, records []carfile.Record, batch []int) ([]models.Car, error) {
+	carReqs := make([]models.CarRequest, len(batch))
+	for j, i := range batch {
+		carReqs[j] = records[i].Car
+	}
+
+	cars, err := s.store.CreateCars(ctx, carReqs)
+	if err != nil {
+		return nil, err
+	}
+	for _, car := range cars {
+		if err := s.audit.Record(ctx, models.AuditCreated, models.EntityCar, car.ID, nil, car); err != nil {
+			return nil, err
+		}
+	}
+	return cars, nil
+}
+
+func markCreated(report *models.ImportReport, batch []int, cars []models.Car) {
+	for j, i := range batch {
+		id := cars[j].ID
+		report.Rows[i].Status = models.ImportRowCreated
+		report.Rows[i].ID = &id
+		report.Created++
+	}
+}
+
+// setRowError copies a domain error into the row; anything else is reported
+// generically so database details stay out of the report.
+func setRowError(row *models.ImportRowResult, err error) {
+	var typed *models.Error
+	if errors.As(err, &typed) {
+		row.Error, row.Fields = typed.Message, typed.Fields
+		return
+	}
+	row.Error = "row could not be saved"
+}
@@ -0,0 +1,47 @@
+package car
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"go.opentelemetry.io/otel"
+)
+
+// SearchCars runs a ranked catalogue search for q. Words that match no car
+// but are close to a known brand are corrected first, so "toyta" searches
+// for Toyota; the corrected query is reported back.
+func (s *CarService) SearchCars(ctx context.Context, q string, limit int) (*models.CarSearchResponse, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "SearchCars-Service")
+	defer span.End()
+
+	terms := models.SearchTerms(q)
+	if len(terms) == 0 {
+		return nil, models.Validation("q must contain at least one letter or digit", models.FieldError{Field: "q", Rule: "required", Message: "must contain at least one letter or digit"})
+	}
+
+	suggestions, err := s.store.SuggestBrands(ctx, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.CarSearchResponse{Query: q}
+	if len(suggestions) > 0 {
+		var corrected []string
+		for _, t := range terms {
+			if brand, ok := suggestions[t]; ok {
+				corrected = append(corrected, models.SearchTerms(brand)...)
+				continue
+			}
+			corrected = append(corrected, t)
+		}
+		terms = corrected
+		resp.CorrectedQuery = strings.Join(terms, " ")
+	}
+
+	if resp.Results, err = s.store.SearchCars(ctx, models.CarSearch{Terms: terms, Limit: limit}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
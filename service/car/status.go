@@ -0,0 +1,62 @@
+package car
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	auditService "github.com/KRAZYFLASH/carZone/service/audit"
+	"go.opentelemetry.io/otel"
+)
+
+// TransitionCar applies a status action such as "reserve" or "sell" and
+// records who made it. Actions not allowed from the car's current status are
+// rejected with a Conflict.
+func (s *CarService) TransitionCar(ctx context.Context, id, action, note string, match models.VersionMatch) (*models.Car, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "TransitionCar-Service")
+	defer span.End()
+
+	t, ok := models.CarTransitions[action]
+	if !ok {
+		return nil, models.NotFound(fmt.Sprintf("unknown car action %q", action))
+	}
+	changedBy, ok := middleware.UsernameFromContext(ctx)
+	if !ok {
+		changedBy = auditService.SystemActor
+	}
+
+	var car models.Car
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetCarForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("car", before.Version); err != nil {
+			return err
+		}
+		if !t.Allows(before.Status) {
+			return models.Conflict(fmt.Sprintf("cannot %s a car that is %s; allowed from: %s",
+				action, before.Status, strings.Join(t.From, ", ")))
+		}
+
+		if car, err = s.store.SetCarStatus(ctx, id, t, before.Status, changedBy, note); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditStatusChanged, models.EntityCar, car.ID, before, car)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+func (s *CarService) ListStatusChanges(ctx context.Context, id string) ([]models.CarStatusChange, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "ListStatusChanges-Service")
+	defer span.End()
+
+	return s.store.ListStatusChanges(ctx, id)
+}
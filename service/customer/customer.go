@@ -0,0 +1,151 @@
+package customer
+
+import (
+	"context"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+type CustomerService struct {
+	store store.CustomerStoreInterface
+	tx    store.TransactorInterface
+	audit service.AuditRecorder
+}
+
+func NewCustomerService(store store.CustomerStoreInterface, tx store.TransactorInterface, audit service.AuditRecorder) *CustomerService {
+	return &CustomerService{store: store, tx: tx, audit: audit}
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id string) (*models.Customer, error) {
+	tracer := otel.Tracer("CustomerService")
+	ctx, span := tracer.Start(ctx, "GetCustomer-Service")
+	defer span.End()
+
+	c, err := s.store.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *CustomerService) ListCustomers(ctx context.Context, filter models.CustomerFilter) (models.CustomerPage, error) {
+	tracer := otel.Tracer("CustomerService")
+	ctx, span := tracer.Start(ctx, "ListCustomers-Service")
+	defer span.End()
+
+	return s.store.ListCustomers(ctx, filter)
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, req *models.CustomerRequest) (*models.Customer, error) {
+	tracer := otel.Tracer("CustomerService")
+	ctx, span := tracer.Start(ctx, "CreateCustomer-Service")
+	defer span.End()
+
+	if err := models.ValidateCustomerRequest(*req); err != nil {
+		return nil, err
+	}
+	createdBy, _ := middleware.UsernameFromContext(ctx)
+
+	var created models.Customer
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.CreateCustomer(ctx, req, createdBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditCreated, models.EntityCustomer, created.ID, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id string, req *models.CustomerRequest, match models.VersionMatch) (*models.Customer, error) {
+	tracer := otel.Tracer("CustomerService")
+	ctx, span := tracer.Start(ctx, "UpdateCustomer-Service")
+	defer span.End()
+
+	if err := models.ValidateCustomerRequest(*req); err != nil {
+		return nil, err
+	}
+
+	var updated models.Customer
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetCustomerForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("customer", before.Version); err != nil {
+			return err
+		}
+		if updated, err = s.store.UpdateCustomer(ctx, id, req); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityCustomer, updated.ID, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteCustomer permanently removes a customer together with their notes,
+// interests and leads. The audit entry keeps the last known details.
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id string, match models.VersionMatch) (*models.Customer, error) {
+	tracer := otel.Tracer("CustomerService")
+	ctx, span := tracer.Start(ctx, "DeleteCustomer-Service")
+	defer span.End()
+
+	var deleted models.Customer
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if deleted, err = s.store.GetCustomerForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if err := match.CheckVersion("customer", deleted.Version); err != nil {
+			return err
+		}
+		if err := s.store.DeleteCustomer(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditDeleted, models.EntityCustomer, deleted.ID, deleted, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+func (s *CustomerService) ListNotes(ctx context.Context, customerID string) ([]models.CustomerNote, error) {
+	tracer := otel.Tracer("CustomerService")
+	ctx, span := tracer.Start(ctx, "ListNotes-Service")
+	defer span.End()
+
+	// Fails with NotFound for unknown customers instead of listing nothing.
+	if _, err := s.store.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	return s.store.ListNotes(ctx, customerID)
+}
+
+// AddNote appends a note to the customer's timeline, signed by the caller.
+func (s *CustomerService) AddNote(ctx context.Context, customerID string, req *models.CustomerNoteRequest) (*models.CustomerNote, error) {
+	tracer := otel.Tracer("CustomerService")
+	ctx, span := tracer.Start(ctx, "AddNote-Service")
+	defer span.End()
+
+	if err := models.ValidateCustomerNoteRequest(*req); err != nil {
+		return nil, err
+	}
+	author, _ := middleware.UsernameFromContext(ctx)
+
+	note, err := s.store.AddNote(ctx, customerID, req.Body, author)
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
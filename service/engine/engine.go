@@ -2,18 +2,28 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
 
+	"github.com/KRAZYFLASH/carZone/jsonpatch"
+	"github.com/KRAZYFLASH/carZone/middleware"
 	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
 	"github.com/KRAZYFLASH/carZone/store"
 	"go.opentelemetry.io/otel"
 )
 
 type EngineService struct {
 	store store.EngineStoreInterface
+	cars  service.CarServiceInterface
+	tx    store.TransactorInterface
+	audit service.AuditRecorder
 }
 
-func NewEngineService(store store.EngineStoreInterface) *EngineService {
-	return &EngineService{store: store}
+func NewEngineService(store store.EngineStoreInterface, cars service.CarServiceInterface, tx store.TransactorInterface, audit service.AuditRecorder) *EngineService {
+	return &EngineService{store: store, cars: cars, tx: tx, audit: audit}
 }
 
 func (s *EngineService) GetEngineById(ctx context.Context, id string) (*models.Engine, error) {
@@ -28,7 +38,32 @@ func (s *EngineService) GetEngineById(ctx context.Context, id string) (*models.E
 	return &engine, nil
 }
 
+func (s *EngineService) ListEngines(ctx context.Context, filter models.EngineFilter) (models.EnginePage, error) {
+	tracer := otel.Tracer("EngineService")
+	ctx, span := tracer.Start(ctx, "ListEngines-Service")
+	defer span.End()
+
+	usage, err := s.store.ListEngines(ctx, filter)
+	if err != nil {
+		return models.EnginePage{}, err
+	}
+
+	page := models.EnginePage{Engines: make([]models.Engine, len(usage.Engines)), NextCursor: usage.NextCursor}
+	for i, u := range usage.Engines {
+		page.Engines[i] = u.Engine
+	}
+	return page, nil
+}
+
+// EngineUsage lists engines with how many cars use each, so unused engines
+// can be found and cleaned up.
+func (s *EngineService) EngineUsage(ctx context.Context, filter models.EngineFilter) (models.EngineUsagePage, error) {
+	tracer := otel.Tracer("EngineService")
+	ctx, span := tracer.Start(ctx, "EngineUsage-Service")
+	defer span.End()
 
+	return s.store.ListEngines(ctx, filter)
+}
 
 func (s *EngineService) CreateEngine(ctx context.Context, engineReq *models.EngineRequest) (*models.Engine, error) {
 	tracer := otel.Tracer("EngineService")
@@ -39,14 +74,21 @@ func (s *EngineService) CreateEngine(ctx context.Context, engineReq *models.Engi
 		return nil, err
 	}
 
-	createdEngine, err := s.store.EngineCreate(ctx, engineReq)
+	var createdEngine models.Engine
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if createdEngine, err = s.store.EngineCreate(ctx, engineReq); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditCreated, models.EntityEngine, createdEngine.EngineID, nil, createdEngine)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &createdEngine, nil
 }
 
-func (s *EngineService) UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest) (*models.Engine, error) {
+func (s *EngineService) UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest, match models.VersionMatch) (*models.Engine, error) {
 	tracer := otel.Tracer("EngineService")
 	ctx, span := tracer.Start(ctx, "UpdateEngine-Service")
 	defer span.End()
@@ -55,21 +97,148 @@ func (s *EngineService) UpdateEngine(ctx context.Context, id string, engineReq *
 		return nil, err
 	}
 
-	updatedEngine, err := s.store.EngineUpdate(ctx, id, engineReq)
+	var updatedEngine models.Engine
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetEngineForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if updatedEngine, err = s.store.EngineUpdate(ctx, id, engineReq, match); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityEngine, updatedEngine.EngineID, before, updatedEngine)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &updatedEngine, nil
 }
 
-func (s *EngineService) DeleteEngine(ctx context.Context, id string) (*models.Engine, error) {
+// PatchEngine applies a merge patch or JSON Patch to the current engine and
+// saves the validated result.
+func (s *EngineService) PatchEngine(ctx context.Context, id string, patch jsonpatch.Patch, match models.VersionMatch) (*models.Engine, error) {
+	tracer := otel.Tracer("EngineService")
+	ctx, span := tracer.Start(ctx, "PatchEngine-Service")
+	defer span.End()
+
+	current, err := s.store.GetEngineById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := match.CheckVersion("engine", current.Version); err != nil {
+		return nil, err
+	}
+
+	var engineReq models.EngineRequest
+	if err := service.ApplyPatch(patch, current.Request(), &engineReq); err != nil {
+		return nil, err
+	}
+
+	// Save against the version the patch was applied to, so a concurrent
+	// write in between is reported instead of silently overwritten.
+	return s.UpdateEngine(ctx, id, &engineReq, models.VersionMatch{current.Version})
+}
+
+// DeleteEngine moves the engine to the trash. An engine still used by live
+// cars is refused unless cascade is set, in which case those cars are moved
+// to the trash with it.
+func (s *EngineService) DeleteEngine(ctx context.Context, id string, cascade bool, match models.VersionMatch) (*models.Engine, error) {
 	tracer := otel.Tracer("EngineService")
 	ctx, span := tracer.Start(ctx, "DeleteEngine-Service")
 	defer span.End()
 
-	deletedEngine, err := s.store.EngineDelete(ctx, id)
+	deletedBy, _ := middleware.UsernameFromContext(ctx)
+
+	var deletedEngine models.Engine
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetEngineForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = match.CheckVersion("engine", before.Version); err != nil {
+			return err
+		}
+		if cascade {
+			if err = s.deleteCars(ctx, id); err != nil {
+				return err
+			}
+		}
+		if deletedEngine, err = s.store.EngineDelete(ctx, id, deletedBy, match); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditDeleted, models.EntityEngine, deletedEngine.EngineID, before, deletedEngine)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &deletedEngine, nil
-}
\ No newline at end of file
+}
+
+// deleteCars moves every live car using the engine to the trash through
+// CarService.DeleteCar, so the cascade refuses the same cars a single delete
+// does.
+func (s *EngineService) deleteCars(ctx context.Context, engineID string) error {
+	// Collect first: the transaction's connection cannot run statements
+	// while the stream is still open.
+	var cars []models.Car
+	err := s.cars.ExportCars(ctx, models.CarFilter{EngineID: engineID}, func(car models.Car) error {
+		cars = append(cars, car)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, car := range cars {
+		if _, err := s.cars.DeleteCar(ctx, car.ID.String(), nil); err != nil {
+			var typed *models.Error
+			if errors.As(err, &typed) {
+				return &models.Error{Kind: typed.Kind, Message: fmt.Sprintf("car %s: %s", car.ID, typed.Message), Fields: typed.Fields}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *EngineService) RestoreEngine(ctx context.Context, id string) (*models.Engine, error) {
+	tracer := otel.Tracer("EngineService")
+	ctx, span := tracer.Start(ctx, "RestoreEngine-Service")
+	defer span.End()
+
+	var restoredEngine models.Engine
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if restoredEngine, err = s.store.EngineRestore(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditRestored, models.EntityEngine, restoredEngine.EngineID, nil, restoredEngine)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &restoredEngine, nil
+}
+
+// RunPurge permanently removes engines that have been in the trash longer than
+// retention, checking every interval until ctx is cancelled.
+func (s *EngineService) RunPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := s.store.PurgeDeleted(ctx, now.Add(-retention))
+			if err != nil {
+				log.Println("Error purging deleted engines:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Purged %d deleted engine(s)", n)
+			}
+		}
+	}
+}
@@ -2,21 +2,110 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/KRAZYFLASH/carZone/carfile"
+	"github.com/KRAZYFLASH/carZone/jsonpatch"
 	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
 )
 
+// AuditRecorder writes the audit entry for a change. It must be called with
+// the context of the transaction that made the change.
+type AuditRecorder interface {
+	Record(ctx context.Context, action, entityType string, entityID uuid.UUID, before, after any) error
+}
+
+type AuditServiceInterface interface {
+	ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error)
+}
+
 type CarServiceInterface interface {
 	GetCarById(ctx context.Context, id string) (*models.Car, error)
-	GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error)
+	ListCars(ctx context.Context, filter models.CarFilter) (models.CarPage, error)
+	SearchCars(ctx context.Context, q string, limit int) (*models.CarSearchResponse, error)
+	ExportCars(ctx context.Context, filter models.CarFilter, fn func(models.Car) error) error
 	CreateCar(ctx context.Context, carReq *models.CarRequest) (*models.Car, error)
-	UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (*models.Car, error)
-	DeleteCar(ctx context.Context, id string) (*models.Car, error)
+	ImportCars(ctx context.Context, records []carfile.Record, mode models.ImportMode) (*models.ImportReport, error)
+	BatchCars(ctx context.Context, req models.BatchRequest) (*models.BatchReport, error)
+	UpdateCar(ctx context.Context, id string, carReq *models.CarRequest, match models.VersionMatch) (*models.Car, error)
+	PatchCar(ctx context.Context, id string, patch jsonpatch.Patch, match models.VersionMatch) (*models.Car, error)
+	DeleteCar(ctx context.Context, id string, match models.VersionMatch) (*models.Car, error)
+	RestoreCar(ctx context.Context, id string) (*models.Car, error)
+	ListCarHistory(ctx context.Context, id string) ([]models.CarRevision, error)
+	TransitionCar(ctx context.Context, id, action, note string, match models.VersionMatch) (*models.Car, error)
+	ListStatusChanges(ctx context.Context, id string) ([]models.CarStatusChange, error)
+	GetCarAsOf(ctx context.Context, id string, t time.Time) (*models.Car, error)
+	RevertCar(ctx context.Context, id string, version int, match models.VersionMatch) (*models.Car, error)
 }
 
 type EngineServiceInterface interface {
 	GetEngineById(ctx context.Context, id string) (*models.Engine, error)
+	ListEngines(ctx context.Context, filter models.EngineFilter) (models.EnginePage, error)
+	EngineUsage(ctx context.Context, filter models.EngineFilter) (models.EngineUsagePage, error)
 	CreateEngine(ctx context.Context, engineReq *models.EngineRequest) (*models.Engine, error)
-	UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest) (*models.Engine, error)
-	DeleteEngine(ctx context.Context, id string) (*models.Engine, error)
-}
\ No newline at end of file
+	UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest, match models.VersionMatch) (*models.Engine, error)
+	PatchEngine(ctx context.Context, id string, patch jsonpatch.Patch, match models.VersionMatch) (*models.Engine, error)
+	DeleteEngine(ctx context.Context, id string, cascade bool, match models.VersionMatch) (*models.Engine, error)
+	RestoreEngine(ctx context.Context, id string) (*models.Engine, error)
+}
+
+type ReservationServiceInterface interface {
+	GetReservation(ctx context.Context, id string) (*models.Reservation, error)
+	ListReservations(ctx context.Context, carID string) ([]models.Reservation, error)
+	CreateReservation(ctx context.Context, carID string, req *models.ReservationRequest) (*models.Reservation, error)
+	ReleaseReservation(ctx context.Context, id string) (*models.Reservation, error)
+}
+
+type OrderServiceInterface interface {
+	GetOrder(ctx context.Context, id string) (*models.Order, error)
+	ListOrders(ctx context.Context, filter models.OrderFilter) (models.OrderPage, error)
+	CreateOrder(ctx context.Context, req *models.OrderRequest) (*models.Order, error)
+	UpdateOrder(ctx context.Context, id string, req *models.OrderRequest, match models.VersionMatch) (*models.Order, error)
+	SetPaymentStatus(ctx context.Context, id string, req *models.PaymentRequest, match models.VersionMatch) (*models.Order, error)
+	CompleteOrder(ctx context.Context, id string, match models.VersionMatch) (*models.Order, error)
+	CancelOrder(ctx context.Context, id string, match models.VersionMatch) (*models.Order, error)
+	GetInvoice(ctx context.Context, id string) (*models.Invoice, error)
+}
+
+type CustomerServiceInterface interface {
+	GetCustomer(ctx context.Context, id string) (*models.Customer, error)
+	ListCustomers(ctx context.Context, filter models.CustomerFilter) (models.CustomerPage, error)
+	CreateCustomer(ctx context.Context, req *models.CustomerRequest) (*models.Customer, error)
+	UpdateCustomer(ctx context.Context, id string, req *models.CustomerRequest, match models.VersionMatch) (*models.Customer, error)
+	DeleteCustomer(ctx context.Context, id string, match models.VersionMatch) (*models.Customer, error)
+	ListNotes(ctx context.Context, customerID string) ([]models.CustomerNote, error)
+	AddNote(ctx context.Context, customerID string, req *models.CustomerNoteRequest) (*models.CustomerNote, error)
+}
+
+type LeadServiceInterface interface {
+	GetLead(ctx context.Context, id string) (*models.Lead, error)
+	ListLeads(ctx context.Context, filter models.LeadFilter) (models.LeadPage, error)
+	CreateLead(ctx context.Context, req *models.LeadRequest) (*models.Lead, error)
+	UpdateLead(ctx context.Context, id string, req *models.LeadRequest, match models.VersionMatch) (*models.Lead, error)
+	AssignLead(ctx context.Context, id, username string, match models.VersionMatch) (*models.Lead, error)
+	DeleteLead(ctx context.Context, id string, match models.VersionMatch) (*models.Lead, error)
+}
+
+type TestDriveServiceInterface interface {
+	GetTestDrive(ctx context.Context, id string) (*models.TestDrive, error)
+	ListTestDrives(ctx context.Context, filter models.TestDriveFilter) ([]models.TestDrive, error)
+	CreateTestDrive(ctx context.Context, carID string, req *models.TestDriveRequest) (*models.TestDrive, error)
+	RescheduleTestDrive(ctx context.Context, id string, req *models.RescheduleRequest, match models.VersionMatch) (*models.TestDrive, error)
+	CancelTestDrive(ctx context.Context, id string, match models.VersionMatch) (*models.TestDrive, error)
+}
+
+type UserServiceInterface interface {
+	Authenticate(ctx context.Context, cred models.Credential) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error)
+	ChangePassword(ctx context.Context, username string, req *models.ChangePasswordRequest) error
+	Deactivate(ctx context.Context, username string) error
+	ChangeRole(ctx context.Context, username string, role models.Role) error
+}
+
+type AuthServiceInterface interface {
+	Login(ctx context.Context, cred models.Credential) (*models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, jti, sessionID string, expiresAt time.Time) error
+}
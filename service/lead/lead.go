@@ -0,0 +1,159 @@
+package lead
+
+import (
+	"context"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+type LeadService struct {
+	store store.LeadStoreInterface
+	tx    store.TransactorInterface
+	audit service.AuditRecorder
+}
+
+func NewLeadService(store store.LeadStoreInterface, tx store.TransactorInterface, audit service.AuditRecorder) *LeadService {
+	return &LeadService{store: store, tx: tx, audit: audit}
+}
+
+func (s *LeadService) GetLead(ctx context.Context, id string) (*models.Lead, error) {
+	tracer := otel.Tracer("LeadService")
+	ctx, span := tracer.Start(ctx, "GetLead-Service")
+	defer span.End()
+
+	l, err := s.store.GetLead(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (s *LeadService) ListLeads(ctx context.Context, filter models.LeadFilter) (models.LeadPage, error) {
+	tracer := otel.Tracer("LeadService")
+	ctx, span := tracer.Start(ctx, "ListLeads-Service")
+	defer span.End()
+
+	return s.store.ListLeads(ctx, filter)
+}
+
+func (s *LeadService) CreateLead(ctx context.Context, req *models.LeadRequest) (*models.Lead, error) {
+	tracer := otel.Tracer("LeadService")
+	ctx, span := tracer.Start(ctx, "CreateLead-Service")
+	defer span.End()
+
+	if err := models.ValidateLeadRequest(*req); err != nil {
+		return nil, err
+	}
+	createdBy, _ := middleware.UsernameFromContext(ctx)
+
+	var created models.Lead
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.CreateLead(ctx, req, createdBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditCreated, models.EntityLead, created.ID, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateLead replaces a lead's details. Leaving status empty keeps the
+// current one.
+func (s *LeadService) UpdateLead(ctx context.Context, id string, req *models.LeadRequest, match models.VersionMatch) (*models.Lead, error) {
+	tracer := otel.Tracer("LeadService")
+	ctx, span := tracer.Start(ctx, "UpdateLead-Service")
+	defer span.End()
+
+	if err := models.ValidateLeadRequest(*req); err != nil {
+		return nil, err
+	}
+
+	var updated models.Lead
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetLeadForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("lead", before.Version); err != nil {
+			return err
+		}
+		if req.Status == "" {
+			req.Status = before.Status
+		}
+		if updated, err = s.store.UpdateLead(ctx, id, req); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityLead, updated.ID, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// AssignLead hands the lead to username, or takes it back when username is
+// empty. A lead already worked by someone else must be unassigned first so
+// leads are not taken over by accident.
+func (s *LeadService) AssignLead(ctx context.Context, id, username string, match models.VersionMatch) (*models.Lead, error) {
+	tracer := otel.Tracer("LeadService")
+	ctx, span := tracer.Start(ctx, "AssignLead-Service")
+	defer span.End()
+
+	var assigned models.Lead
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetLeadForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("lead", before.Version); err != nil {
+			return err
+		}
+		if username != "" && before.AssignedTo != "" && before.AssignedTo != username {
+			return models.Conflict("lead is assigned to " + before.AssignedTo + "; unassign it first")
+		}
+		if before.AssignedTo == username {
+			assigned = before
+			return nil
+		}
+		if assigned, err = s.store.AssignLead(ctx, id, username); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityLead, assigned.ID, before, assigned)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &assigned, nil
+}
+
+func (s *LeadService) DeleteLead(ctx context.Context, id string, match models.VersionMatch) (*models.Lead, error) {
+	tracer := otel.Tracer("LeadService")
+	ctx, span := tracer.Start(ctx, "DeleteLead-Service")
+	defer span.End()
+
+	var deleted models.Lead
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if deleted, err = s.store.GetLeadForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if err := match.CheckVersion("lead", deleted.Version); err != nil {
+			return err
+		}
+		if err := s.store.DeleteLead(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditDeleted, models.EntityLead, deleted.ID, deleted, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
@@ -0,0 +1,252 @@
+package order
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+var errOrderClosed = models.Conflict("order is no longer open and its terms can no longer be changed")
+
+type OrderService struct {
+	store store.OrderStoreInterface
+	cars  service.CarServiceInterface
+	tx    store.TransactorInterface
+	audit service.AuditRecorder
+}
+
+func NewOrderService(store store.OrderStoreInterface, cars service.CarServiceInterface, tx store.TransactorInterface, audit service.AuditRecorder) *OrderService {
+	return &OrderService{store: store, cars: cars, tx: tx, audit: audit}
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, id string) (*models.Order, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "GetOrder-Service")
+	defer span.End()
+
+	o, err := s.store.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (s *OrderService) ListOrders(ctx context.Context, filter models.OrderFilter) (models.OrderPage, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "ListOrders-Service")
+	defer span.End()
+
+	return s.store.ListOrders(ctx, filter)
+}
+
+// checkSellable fails with a Conflict unless the car could be sold now.
+func (s *OrderService) checkSellable(ctx context.Context, carID string) error {
+	car, err := s.cars.GetCarById(ctx, carID)
+	if err != nil {
+		return err
+	}
+	if !models.CarTransitions["sell"].Allows(car.Status) {
+		return models.Conflict(fmt.Sprintf("car is %s and cannot be sold", car.Status))
+	}
+	return nil
+}
+
+// CreateOrder opens an order for a car that is available or reserved. The
+// car stays as it is until the order completes.
+func (s *OrderService) CreateOrder(ctx context.Context, req *models.OrderRequest) (*models.Order, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "CreateOrder-Service")
+	defer span.End()
+
+	if err := models.ValidateOrderRequest(*req); err != nil {
+		return nil, err
+	}
+	createdBy, _ := middleware.UsernameFromContext(ctx)
+
+	var created models.Order
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.checkSellable(ctx, req.CarID); err != nil {
+			return err
+		}
+		var err error
+		if created, err = s.store.CreateOrder(ctx, req, createdBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditCreated, models.EntityOrder, created.ID, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateOrder replaces the terms of an open order. Leaving payment_status
+// empty keeps the current one.
+func (s *OrderService) UpdateOrder(ctx context.Context, id string, req *models.OrderRequest, match models.VersionMatch) (*models.Order, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "UpdateOrder-Service")
+	defer span.End()
+
+	if err := models.ValidateOrderRequest(*req); err != nil {
+		return nil, err
+	}
+
+	var updated models.Order
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetOrderForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("order", before.Version); err != nil {
+			return err
+		}
+		if before.Status != models.OrderOpen {
+			return errOrderClosed
+		}
+		if req.CarID != before.CarID.String() {
+			if err := s.checkSellable(ctx, req.CarID); err != nil {
+				return err
+			}
+		}
+		if req.PaymentStatus == "" {
+			req.PaymentStatus = before.PaymentStatus
+		}
+
+		if updated, err = s.store.UpdateOrder(ctx, id, req); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityOrder, updated.ID, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// SetPaymentStatus records a payment update. Unlike the other terms it can
+// change after the order completes or is cancelled, e.g. for a refund.
+func (s *OrderService) SetPaymentStatus(ctx context.Context, id string, req *models.PaymentRequest, match models.VersionMatch) (*models.Order, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "SetPaymentStatus-Service")
+	defer span.End()
+
+	if err := models.ValidatePaymentRequest(*req); err != nil {
+		return nil, err
+	}
+
+	var updated models.Order
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetOrderForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("order", before.Version); err != nil {
+			return err
+		}
+		if updated, err = s.store.SetPaymentStatus(ctx, id, req.PaymentStatus); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityOrder, updated.ID, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// CompleteOrder closes the order and sells its car in one transaction, so a
+// car is never sold without a completed order or the other way round. A
+// reserved car's hold is fulfilled by the sale.
+func (s *OrderService) CompleteOrder(ctx context.Context, id string, match models.VersionMatch) (*models.Order, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "CompleteOrder-Service")
+	defer span.End()
+
+	completedBy, _ := middleware.UsernameFromContext(ctx)
+
+	var completed models.Order
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetOrderForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("order", before.Version); err != nil {
+			return err
+		}
+		if before.Status != models.OrderOpen {
+			return errOrderClosed
+		}
+
+		note := "order " + models.InvoiceNumber(before.Number)
+		if _, err := s.cars.TransitionCar(ctx, before.CarID.String(), "sell", note, nil); err != nil {
+			return err
+		}
+		if completed, err = s.store.SetOrderStatus(ctx, id, models.OrderCompleted, completedBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditStatusChanged, models.EntityOrder, completed.ID, before, completed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &completed, nil
+}
+
+// CancelOrder cancels an open order. The order is kept for the record and
+// the car can be ordered again.
+func (s *OrderService) CancelOrder(ctx context.Context, id string, match models.VersionMatch) (*models.Order, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "CancelOrder-Service")
+	defer span.End()
+
+	cancelledBy, _ := middleware.UsernameFromContext(ctx)
+
+	var cancelled models.Order
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetOrderForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("order", before.Version); err != nil {
+			return err
+		}
+		if before.Status != models.OrderOpen {
+			return errOrderClosed
+		}
+		if cancelled, err = s.store.SetOrderStatus(ctx, id, models.OrderCancelled, cancelledBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditStatusChanged, models.EntityOrder, cancelled.ID, before, cancelled)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cancelled, nil
+}
+
+// GetInvoice builds the invoice of a completed order.
+func (s *OrderService) GetInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	tracer := otel.Tracer("OrderService")
+	ctx, span := tracer.Start(ctx, "GetInvoice-Service")
+	defer span.End()
+
+	o, err := s.store.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != models.OrderCompleted {
+		return nil, models.Conflict("order has not been completed; invoices are issued on completion")
+	}
+	car, err := s.cars.GetCarById(ctx, o.CarID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	inv := models.NewInvoice(o, *car)
+	return &inv, nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/KRAZYFLASH/carZone/jsonpatch"
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+// ApplyPatch applies patch to the JSON form of current and decodes the
+// result into target, translating patch failures into domain errors.
+func ApplyPatch(patch jsonpatch.Patch, current, target any) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	patched, err := patch.Apply(doc)
+	if err == nil {
+		err = jsonpatch.DecodeStrict(patched, target)
+	}
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return models.Conflict(err.Error())
+	default:
+		return models.BadRequest(err.Error())
+	}
+}
@@ -0,0 +1,168 @@
+package reservation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	auditService "github.com/KRAZYFLASH/carZone/service/audit"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+type ReservationService struct {
+	store store.ReservationStoreInterface
+	cars  service.CarServiceInterface
+	tx    store.TransactorInterface
+	audit service.AuditRecorder
+}
+
+func NewReservationService(store store.ReservationStoreInterface, cars service.CarServiceInterface, tx store.TransactorInterface, audit service.AuditRecorder) *ReservationService {
+	return &ReservationService{store: store, cars: cars, tx: tx, audit: audit}
+}
+
+func (s *ReservationService) GetReservation(ctx context.Context, id string) (*models.Reservation, error) {
+	tracer := otel.Tracer("ReservationService")
+	ctx, span := tracer.Start(ctx, "GetReservation-Service")
+	defer span.End()
+
+	r, err := s.store.GetReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *ReservationService) ListReservations(ctx context.Context, carID string) ([]models.Reservation, error) {
+	tracer := otel.Tracer("ReservationService")
+	ctx, span := tracer.Start(ctx, "ListReservations-Service")
+	defer span.End()
+
+	// Fails with NotFound for unknown cars instead of listing nothing.
+	if _, err := s.cars.GetCarById(ctx, carID); err != nil {
+		return nil, err
+	}
+	return s.store.ListReservations(ctx, carID)
+}
+
+// CreateReservation reserves an available car and holds it for the customer
+// until the hold expires. The car moves to reserved in the same transaction.
+func (s *ReservationService) CreateReservation(ctx context.Context, carID string, req *models.ReservationRequest) (*models.Reservation, error) {
+	tracer := otel.Tracer("ReservationService")
+	ctx, span := tracer.Start(ctx, "CreateReservation-Service")
+	defer span.End()
+
+	if err := models.ValidateReservationRequest(*req); err != nil {
+		return nil, err
+	}
+	hours := req.HoldHours
+	if hours == 0 {
+		hours = models.DefaultHoldHours
+	}
+	expiresAt := time.Now().Add(time.Duration(hours) * time.Hour)
+	createdBy, _ := middleware.UsernameFromContext(ctx)
+
+	var created models.Reservation
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.cars.TransitionCar(ctx, carID, "reserve", req.Note, nil); err != nil {
+			return err
+		}
+		var err error
+		if created, err = s.store.CreateReservation(ctx, carID, req, expiresAt, createdBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditCreated, models.EntityReservation, created.ID, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ReleaseReservation ends an active hold early and makes the car available
+// again.
+func (s *ReservationService) ReleaseReservation(ctx context.Context, id string) (*models.Reservation, error) {
+	tracer := otel.Tracer("ReservationService")
+	ctx, span := tracer.Start(ctx, "ReleaseReservation-Service")
+	defer span.End()
+
+	closedBy, _ := middleware.UsernameFromContext(ctx)
+
+	var released models.Reservation
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		released, err = s.close(ctx, id, models.ReservationReleased, closedBy, models.AuditUpdated)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &released, nil
+}
+
+// close ends the hold with status and moves its car back to available. It
+// must run inside a transaction.
+func (s *ReservationService) close(ctx context.Context, id, status, closedBy, action string) (models.Reservation, error) {
+	before, err := s.store.GetReservationForUpdate(ctx, id)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	after, err := s.store.CloseReservation(ctx, id, status, closedBy)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	if _, err := s.cars.TransitionCar(ctx, after.CarID.String(), "release", "reservation "+status, nil); err != nil {
+		return models.Reservation{}, err
+	}
+	return after, s.audit.Record(ctx, action, models.EntityReservation, after.ID, before, after)
+}
+
+// RunExpirer releases holds that have passed their expiry, checking every
+// interval until ctx is cancelled.
+func (s *ReservationService) RunExpirer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired, err := s.store.ListExpired(ctx, now)
+			if err != nil {
+				log.Println("Error listing expired reservations:", err)
+				continue
+			}
+			n := 0
+			for _, r := range expired {
+				if err := s.expire(ctx, r.ID.String(), now); err != nil {
+					log.Printf("Error expiring reservation %s: %v", r.ID, err)
+					continue
+				}
+				n++
+			}
+			if n > 0 {
+				log.Printf("Expired %d reservation(s)", n)
+			}
+		}
+	}
+}
+
+// expire closes one lapsed hold. The hold is re-read under lock so one
+// released meanwhile is left alone.
+func (s *ReservationService) expire(ctx context.Context, id string, now time.Time) error {
+	return s.tx.WithTx(ctx, func(ctx context.Context) error {
+		r, err := s.store.GetReservationForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if r.Status != models.ReservationActive || r.ExpiresAt.After(now) {
+			return nil
+		}
+		_, err = s.close(ctx, id, models.ReservationExpired, auditService.SystemActor, models.AuditExpired)
+		return err
+	})
+}
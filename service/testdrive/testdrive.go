@@ -0,0 +1,197 @@
+package testdrive
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/middleware"
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/service"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+type TestDriveService struct {
+	store store.TestDriveStoreInterface
+	cars  store.CarStoreInterface
+	users service.UserServiceInterface
+	tx    store.TransactorInterface
+	audit service.AuditRecorder
+}
+
+func NewTestDriveService(store store.TestDriveStoreInterface, cars store.CarStoreInterface, users service.UserServiceInterface, tx store.TransactorInterface, audit service.AuditRecorder) *TestDriveService {
+	return &TestDriveService{store: store, cars: cars, users: users, tx: tx, audit: audit}
+}
+
+func (s *TestDriveService) GetTestDrive(ctx context.Context, id string) (*models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveService")
+	ctx, span := tracer.Start(ctx, "GetTestDrive-Service")
+	defer span.End()
+
+	d, err := s.store.GetTestDrive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *TestDriveService) ListTestDrives(ctx context.Context, filter models.TestDriveFilter) ([]models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveService")
+	ctx, span := tracer.Start(ctx, "ListTestDrives-Service")
+	defer span.End()
+
+	// Fails with NotFound for unknown cars and users instead of listing
+	// nothing.
+	if filter.CarID != "" {
+		if _, err := s.cars.GetCarById(ctx, filter.CarID); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Salesperson != "" {
+		if _, err := s.users.GetUserByUsername(ctx, filter.Salesperson); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.ListTestDrives(ctx, filter)
+}
+
+// CreateTestDrive books an available car for a customer. The salesperson
+// defaults to the caller.
+func (s *TestDriveService) CreateTestDrive(ctx context.Context, carID string, req *models.TestDriveRequest) (*models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveService")
+	ctx, span := tracer.Start(ctx, "CreateTestDrive-Service")
+	defer span.End()
+
+	if err := models.ValidateTestDriveRequest(*req); err != nil {
+		return nil, err
+	}
+	createdBy, _ := middleware.UsernameFromContext(ctx)
+	if strings.TrimSpace(req.Salesperson) == "" {
+		req.Salesperson = createdBy
+	}
+	if err := s.checkSalesperson(ctx, req.Salesperson); err != nil {
+		return nil, err
+	}
+
+	var created models.TestDrive
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.checkBookable(ctx, carID); err != nil {
+			return err
+		}
+		var err error
+		if created, err = s.store.CreateTestDrive(ctx, carID, req, createdBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditCreated, models.EntityTestDrive, created.ID, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// RescheduleTestDrive moves a scheduled drive to another slot, optionally
+// handing it to another salesperson. The car must still be available.
+func (s *TestDriveService) RescheduleTestDrive(ctx context.Context, id string, req *models.RescheduleRequest, match models.VersionMatch) (*models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveService")
+	ctx, span := tracer.Start(ctx, "RescheduleTestDrive-Service")
+	defer span.End()
+
+	if err := models.ValidateRescheduleRequest(*req); err != nil {
+		return nil, err
+	}
+
+	var updated models.TestDrive
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetTestDriveForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("test drive", before.Version); err != nil {
+			return err
+		}
+		if before.Status != models.TestDriveScheduled {
+			return models.Conflict("test drive is " + before.Status + " and cannot be rescheduled")
+		}
+		salesperson := strings.TrimSpace(req.Salesperson)
+		if salesperson == "" {
+			salesperson = before.Salesperson
+		} else if err := s.checkSalesperson(ctx, salesperson); err != nil {
+			return err
+		}
+		if err := s.checkBookable(ctx, before.CarID.String()); err != nil {
+			return err
+		}
+		if updated, err = s.store.RescheduleTestDrive(ctx, id, salesperson, req.StartsAt, req.EndsAt); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityTestDrive, updated.ID, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// CancelTestDrive cancels a scheduled drive, freeing the slot for the car
+// and the salesperson.
+func (s *TestDriveService) CancelTestDrive(ctx context.Context, id string, match models.VersionMatch) (*models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveService")
+	ctx, span := tracer.Start(ctx, "CancelTestDrive-Service")
+	defer span.End()
+
+	cancelledBy, _ := middleware.UsernameFromContext(ctx)
+
+	var cancelled models.TestDrive
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.store.GetTestDriveForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := match.CheckVersion("test drive", before.Version); err != nil {
+			return err
+		}
+		if cancelled, err = s.store.CancelTestDrive(ctx, id, cancelledBy); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditUpdated, models.EntityTestDrive, cancelled.ID, before, cancelled)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cancelled, nil
+}
+
+// checkBookable locks the car until the transaction ends, so it cannot be
+// reserved or sold while the drive is booked, and requires it to be
+// available.
+func (s *TestDriveService) checkBookable(ctx context.Context, carID string) error {
+	car, err := s.cars.GetCarForUpdate(ctx, carID)
+	if err != nil {
+		return err
+	}
+	if car.Status != models.StatusAvailable {
+		return models.Conflict("car is " + car.Status + "; only available cars can be booked for a test drive")
+	}
+	return nil
+}
+
+// checkSalesperson requires username to be an active user who may sell
+// cars.
+func (s *TestDriveService) checkSalesperson(ctx context.Context, username string) error {
+	invalid := func(message string) error {
+		return models.Validation("salesperson "+message, models.FieldError{Field: "salesperson", Rule: "salesperson", Message: message})
+	}
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return invalid("does not exist")
+	}
+	if err != nil {
+		return err
+	}
+	if !user.Active || !user.Role.Can(models.PermCarSell) {
+		return invalid("is not an active salesperson")
+	}
+	return nil
+}
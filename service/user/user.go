@@ -0,0 +1,160 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the username does not exist, so a
+// failed login takes the same time whether or not the user is real.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("carzone-dummy-password"), bcrypt.DefaultCost)
+
+type UserService struct {
+	store    store.UserStoreInterface
+	sessions store.SessionStoreInterface
+}
+
+func NewUserService(store store.UserStoreInterface, sessions store.SessionStoreInterface) *UserService {
+	return &UserService{store: store, sessions: sessions}
+}
+
+func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "GetUserByUsername-Service")
+	defer span.End()
+
+	user, err := s.store.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *UserService) Authenticate(ctx context.Context, cred models.Credential) (*models.User, error) {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "Authenticate-Service")
+	defer span.End()
+
+	user, err := s.store.GetUserByUsername(ctx, cred.Username)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(cred.Password))
+			return nil, models.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(cred.Password)); err != nil {
+		return nil, models.ErrInvalidCredentials
+	}
+	if !user.Active {
+		return nil, models.ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+func (s *UserService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "Register-Service")
+	defer span.End()
+
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if err := models.ValidateRegisterRequest(*req); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.store.CreateUser(ctx, req.Username, string(hash), req.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword replaces the user's password and logs them out everywhere.
+func (s *UserService) ChangePassword(ctx context.Context, username string, req *models.ChangePasswordRequest) error {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "ChangePassword-Service")
+	defer span.End()
+
+	if _, err := s.Authenticate(ctx, models.Credential{Username: username, Password: req.OldPassword}); err != nil {
+		return err
+	}
+	if err := models.ValidateChangePasswordRequest(*req); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdatePassword(ctx, username, string(hash)); err != nil {
+		return err
+	}
+	// Semua sesi dicabut, termasuk sesi pemanggil, supaya token yang bocor
+	// tidak berlaku lagi setelah password diganti.
+	return s.sessions.RevokeUserSessions(ctx, username)
+}
+
+func (s *UserService) Deactivate(ctx context.Context, username string) error {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "Deactivate-Service")
+	defer span.End()
+
+	if err := s.store.SetActive(ctx, username, false); err != nil {
+		return err
+	}
+	// Token yang sudah beredar ikut mati lewat pencabutan sesi.
+	return s.sessions.RevokeUserSessions(ctx, username)
+}
+
+func (s *UserService) ChangeRole(ctx context.Context, username string, role models.Role) error {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "ChangeRole-Service")
+	defer span.End()
+
+	if err := models.ValidateRole(role); err != nil {
+		return err
+	}
+	if err := s.store.SetRole(ctx, username, role); err != nil {
+		return err
+	}
+	// Role ikut tertanam di JWT, jadi sesi lama dicabut supaya role baru
+	// langsung berlaku.
+	return s.sessions.RevokeUserSessions(ctx, username)
+}
+
+// EnsureUser creates the user if it does not exist yet, or gives an
+// existing one role. It is used at boot to provision the initial admin
+// account from the environment; accounts that predate roles start as
+// viewers, so this is what promotes the bootstrap admin.
+func (s *UserService) EnsureUser(ctx context.Context, username, password string, role models.Role) error {
+	user, err := s.store.GetUserByUsername(ctx, username)
+	if err == nil {
+		if user.Role == role {
+			return nil
+		}
+		return s.ChangeRole(ctx, username, role)
+	}
+	if !errors.Is(err, models.ErrUserNotFound) {
+		return err
+	}
+
+	_, err = s.Register(ctx, &models.RegisterRequest{Username: username, Password: password, Role: role})
+	if errors.Is(err, models.ErrUserExists) {
+		// replika lain sudah membuatnya duluan
+		return nil
+	}
+	return err
+}
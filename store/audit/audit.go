@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+type AuditStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+// Record inserts entry. Called with a context from store.Transactor it joins
+// that transaction, so the entry commits or rolls back with the change.
+func (s AuditStore) Record(ctx context.Context, entry models.AuditEntry) error {
+	tracer := otel.Tracer("AuditStore")
+	ctx, span := tracer.Start(ctx, "Record-Store")
+	defer span.End()
+
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.Conn(ctx, s.db).ExecContext(ctx,
+		`INSERT INTO audit_log (actor, action, entity_type, entity_id, before, after, changes, request_id)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		entry.Actor, entry.Action, entry.EntityType, entry.EntityID,
+		nullJSON(entry.Before), nullJSON(entry.After), changes, entry.RequestID,
+	)
+	return err
+}
+
+func (s AuditStore) ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+	tracer := otel.Tracer("AuditStore")
+	ctx, span := tracer.Start(ctx, "ListAudit-Store")
+	defer span.End()
+
+	var (
+		args  store.Args
+		where = []string{"entity_type = " + args.Add(filter.EntityType)}
+	)
+	if filter.EntityID != nil {
+		where = append(where, "entity_id = "+args.Add(*filter.EntityID))
+	}
+
+	order := []store.OrderBy{{Column: "id", Cast: "bigint", Desc: true}}
+	if filter.Cursor != "" {
+		cursor, err := store.DecodeCursor(filter.Cursor, len(order))
+		if err != nil {
+			return models.AuditPage{}, err
+		}
+		where = append(where, store.KeysetCondition(order, cursor, &args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > models.MaxPageSize {
+		limit = models.DefaultPageSize
+	}
+
+	query := `SELECT id, actor, action, entity_type, entity_id, before, after, changes, request_id, created_at
+         FROM audit_log WHERE ` + strings.Join(where, " AND ") + " " + store.OrderClause(order) + " LIMIT " + args.Add(limit+1)
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.AuditPage{}, err
+	}
+	defer rows.Close()
+
+	page := models.AuditPage{Entries: []models.AuditEntry{}}
+	for rows.Next() {
+		var (
+			entry         models.AuditEntry
+			before, after []byte
+			changes       []byte
+		)
+		err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &changes, &entry.RequestID, &entry.CreatedAt)
+		if err != nil {
+			return models.AuditPage{}, err
+		}
+		entry.Before, entry.After = before, after
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return models.AuditPage{}, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return models.AuditPage{}, err
+	}
+
+	if len(page.Entries) > limit {
+		page.Entries = page.Entries[:limit]
+		page.NextCursor = store.EncodeCursor([]string{strconv.FormatInt(page.Entries[limit-1].ID, 10)})
+	}
+	return page, nil
+}
+
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.opentelemetry.io/otel"
 )
 
@@ -15,6 +19,146 @@ type Store struct {
 	db *sql.DB
 }
 
+var (
+	errCarNotFound = models.NotFound("car not found")
+	errCarOrdered  = models.Conflict("car has an open order; cancel the order before deleting the car")
+)
+
+// carColumns is the select list read by scanCar; queries alias car as c and
+// engine as e.
+const carColumns = `c.id, c.name, c.brand, c.year, c.fuel_type, c.price, c.created_at, c.updated_at, c.version,
+  c.deleted_at, c.deleted_by, c.status, c.status_changed_at, c.status_changed_by, e.id, e.displacement, e.no_of_cylinders, e.car_range, e.horsepower, e.torque, e.transmission,
+  e.battery_kwh, e.charging_standard, e.emissions_class, e.version`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCar(row scanner) (models.Car, error) {
+	var (
+		car             models.Car
+		deletedAt       sql.NullTime
+		deletedBy       sql.NullString
+		statusChangedAt sql.NullTime
+		statusChangedBy sql.NullString
+	)
+	err := row.Scan(
+		&car.ID, &car.Name, &car.Brand, &car.Year, &car.FuelType, &car.Price, &car.CreatedAt, &car.UpdatedAt, &car.Version,
+		&deletedAt, &deletedBy, &car.Status, &statusChangedAt, &statusChangedBy,
+		&car.Engine.EngineID, &car.Engine.Displacement, &car.Engine.NoOfCylinders, &car.Engine.CarRange, &car.Engine.Horsepower, &car.Engine.Torque,
+		&car.Engine.Transmission, &car.Engine.BatteryKWh, &car.Engine.ChargingStandard, &car.Engine.EmissionsClass, &car.Engine.Version,
+	)
+	if deletedAt.Valid {
+		car.DeletedAt = &deletedAt.Time
+	}
+	car.DeletedBy = deletedBy.String
+	if statusChangedAt.Valid {
+		car.StatusChangedAt = &statusChangedAt.Time
+	}
+	car.StatusChangedBy = statusChangedBy.String
+	return car, err
+}
+
+type sortKey struct {
+	order store.OrderBy
+	value func(models.Car) string
+}
+
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+var sortKeys = map[string]sortKey{
+	"name":         {store.OrderBy{Column: "c.name", Cast: "text"}, func(c models.Car) string { return c.Name }},
+	"brand":        {store.OrderBy{Column: "c.brand", Cast: "text"}, func(c models.Car) string { return c.Brand }},
+	"year":         {store.OrderBy{Column: "c.year", Cast: "text"}, func(c models.Car) string { return c.Year }},
+	"price":        {store.OrderBy{Column: "c.price", Cast: "numeric"}, func(c models.Car) string { return strconv.FormatFloat(c.Price, 'f', -1, 64) }},
+	"created_at":   {store.OrderBy{Column: "c.created_at", Cast: "timestamp"}, func(c models.Car) string { return c.CreatedAt.Format(timestampLayout) }},
+	"updated_at":   {store.OrderBy{Column: "c.updated_at", Cast: "timestamp"}, func(c models.Car) string { return c.UpdatedAt.Format(timestampLayout) }},
+	"displacement": {store.OrderBy{Column: "e.displacement", Cast: "int"}, func(c models.Car) string { return strconv.FormatInt(c.Engine.Displacement, 10) }},
+	"cylinders":    {store.OrderBy{Column: "e.no_of_cylinders", Cast: "int"}, func(c models.Car) string { return strconv.Itoa(c.Engine.NoOfCylinders) }},
+	"range":        {store.OrderBy{Column: "e.car_range", Cast: "int"}, func(c models.Car) string { return strconv.FormatInt(c.Engine.CarRange, 10) }},
+}
+
+var idSortKey = sortKey{store.OrderBy{Column: "c.id", Cast: "uuid"}, func(c models.Car) string { return c.ID.String() }}
+
+// carOrdering resolves the requested sort into ordering keys, always ending
+// with c.id so the order is total and cursors are unambiguous.
+func carOrdering(sort []models.SortField) ([]store.OrderBy, []sortKey) {
+	keys := make([]sortKey, 0, len(sort)+1)
+	for _, f := range sort {
+		key, ok := sortKeys[f.Field]
+		if !ok {
+			continue
+		}
+		key.order.Desc = f.Desc
+		keys = append(keys, key)
+	}
+	keys = append(keys, idSortKey)
+
+	order := make([]store.OrderBy, len(keys))
+	for i, k := range keys {
+		order[i] = k.order
+	}
+	return order, keys
+}
+
+// filterConditions turns the filter fields of a listing into WHERE
+// conditions, adding their values to args.
+func filterConditions(filter models.CarFilter, args *store.Args) []string {
+	var where []string
+
+	if filter.Deleted {
+		where = append(where, "c.deleted_at IS NOT NULL")
+	} else {
+		where = append(where, "c.deleted_at IS NULL")
+	}
+	if filter.Brand != "" {
+		where = append(where, "c.brand = "+args.Add(filter.Brand))
+	}
+	if filter.FuelType != "" {
+		where = append(where, "c.fuel_type = "+args.Add(filter.FuelType))
+	}
+	if filter.Name != "" {
+		where = append(where, "c.name ILIKE "+args.Add(store.LikePattern(filter.Name)))
+	}
+	if filter.EngineID != "" {
+		where = append(where, "c.engine_id = "+args.Add(filter.EngineID))
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, "c.status = ANY("+args.Add(pq.Array(filter.Statuses))+")")
+	}
+	if filter.YearMin != nil {
+		where = append(where, "c.year::int >= "+args.Add(*filter.YearMin))
+	}
+	if filter.YearMax != nil {
+		where = append(where, "c.year::int <= "+args.Add(*filter.YearMax))
+	}
+	if filter.PriceMin != nil {
+		where = append(where, "c.price >= "+args.Add(*filter.PriceMin))
+	}
+	if filter.PriceMax != nil {
+		where = append(where, "c.price <= "+args.Add(*filter.PriceMax))
+	}
+	if filter.DisplacementMin != nil {
+		where = append(where, "e.displacement >= "+args.Add(*filter.DisplacementMin))
+	}
+	if filter.DisplacementMax != nil {
+		where = append(where, "e.displacement <= "+args.Add(*filter.DisplacementMax))
+	}
+	if filter.CylindersMin != nil {
+		where = append(where, "e.no_of_cylinders >= "+args.Add(*filter.CylindersMin))
+	}
+	if filter.CylindersMax != nil {
+		where = append(where, "e.no_of_cylinders <= "+args.Add(*filter.CylindersMax))
+	}
+	if filter.RangeMin != nil {
+		where = append(where, "e.car_range >= "+args.Add(*filter.RangeMin))
+	}
+	if filter.RangeMax != nil {
+		where = append(where, "e.car_range <= "+args.Add(*filter.RangeMax))
+	}
+	return where
+}
+
 func New(db *sql.DB) *Store {
 	return &Store{db: db}
 }
@@ -24,86 +168,125 @@ func (s Store) GetCarById(ctx context.Context, id string) (models.Car, error) {
 	ctx, span := tracer.Start(ctx, "GetCarById-Store")
 	defer span.End()
 
-	var car models.Car
-
-	query := `
-SELECT
-  c.id, c.name, c.brand, c.year, c.fuel_type, c.price, c.created_at, c.updated_at,
-  e.id, e.displacement, e.no_of_cylinders, e.car_range
-FROM car c
-LEFT JOIN engine e ON c.engine_id = e.id
-WHERE c.id = $1
-`
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&car.ID, &car.Name, &car.Brand, &car.Year, &car.FuelType, &car.Price, &car.CreatedAt, &car.UpdatedAt,
-		&car.Engine.EngineID, &car.Engine.Displacement, &car.Engine.NoOfCylinders, &car.Engine.CarRange,
-	)
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1 AND c.deleted_at IS NULL"
+	car, err := scanCar(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			// pilihan: balikan kosong tanpa error
-			return models.Car{}, nil
+			return models.Car{}, errCarNotFound
 		}
 		return models.Car{}, err
 	}
 	return car, nil
 }
 
-func (s Store) GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error) {
+// GetCarForUpdate reads a live car and locks its row until the surrounding
+// transaction ends, so the value read is the one the next write replaces.
+func (s Store) GetCarForUpdate(ctx context.Context, id string) (models.Car, error) {
 	tracer := otel.Tracer("CarStore")
-	ctx, span := tracer.Start(ctx, "GetCarByBrand-Store")
+	ctx, span := tracer.Start(ctx, "GetCarForUpdate-Store")
 	defer span.End()
 
-	var (
-		cars  []models.Car
-		query string
-	)
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1 AND c.deleted_at IS NULL FOR UPDATE OF c"
+	car, err := scanCar(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Car{}, errCarNotFound
+		}
+		return models.Car{}, err
+	}
+	return car, nil
+}
 
-	if isEngine {
-		query = `
-SELECT
-  c.id, c.name, c.brand, c.year, c.fuel_type, c.price, c.created_at, c.updated_at,
-  e.id, e.displacement, e.no_of_cylinders, e.car_range
-FROM car c
-LEFT JOIN engine e ON c.engine_id = e.id
-WHERE c.brand = $1
-`
-	} else {
-		query = `
-SELECT
-  c.id, c.name, c.brand, c.year, c.fuel_type, c.price, c.created_at, c.updated_at
-FROM car c
-WHERE c.brand = $1
-`
+// ListCars returns one page of cars matching filter, ordered by filter.Sort
+// and paginated with a keyset cursor so pages stay stable under inserts.
+func (s Store) ListCars(ctx context.Context, filter models.CarFilter) (models.CarPage, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "ListCars-Store")
+	defer span.End()
+
+	var args store.Args
+	where := filterConditions(filter, &args)
+
+	order, keys := carOrdering(filter.Sort)
+	if filter.Cursor != "" {
+		cursor, err := store.DecodeCursor(filter.Cursor, len(order))
+		if err != nil {
+			return models.CarPage{}, err
+		}
+		where = append(where, store.KeysetCondition(order, cursor, &args))
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, brand)
+	limit := filter.Limit
+	if limit <= 0 || limit > models.MaxPageSize {
+		limit = models.DefaultPageSize
+	}
+
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id" +
+		" WHERE " + strings.Join(where, " AND ")
+	// Ambil satu baris ekstra untuk tahu apakah masih ada halaman berikutnya.
+	query += " " + store.OrderClause(order) + " LIMIT " + args.Add(limit+1)
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return models.CarPage{}, err
 	}
 	defer rows.Close()
 
+	page := models.CarPage{Cars: []models.Car{}}
 	for rows.Next() {
-		var car models.Car
-		if isEngine {
-			if err := rows.Scan(
-				&car.ID, &car.Name, &car.Brand, &car.Year, &car.FuelType, &car.Price, &car.CreatedAt, &car.UpdatedAt,
-				&car.Engine.EngineID, &car.Engine.Displacement, &car.Engine.NoOfCylinders, &car.Engine.CarRange,
-			); err != nil {
-				return nil, err
-			}
-		} else {
-			if err := rows.Scan(
-				&car.ID, &car.Name, &car.Brand, &car.Year, &car.FuelType, &car.Price, &car.CreatedAt, &car.UpdatedAt,
-			); err != nil {
-				return nil, err
-			}
+		car, err := scanCar(rows)
+		if err != nil {
+			return models.CarPage{}, err
 		}
-		cars = append(cars, car)
+		page.Cars = append(page.Cars, car)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return models.CarPage{}, err
 	}
-	return cars, nil
+
+	if len(page.Cars) > limit {
+		page.Cars = page.Cars[:limit]
+		last := page.Cars[limit-1]
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = key.value(last)
+		}
+		page.NextCursor = store.EncodeCursor(values)
+	}
+	return page, nil
+}
+
+// StreamCars calls fn for every car matching the filter fields and sort of
+// filter, reading rows from the database cursor one at a time. Limit and
+// Cursor are ignored.
+func (s Store) StreamCars(ctx context.Context, filter models.CarFilter, fn func(models.Car) error) error {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "StreamCars-Store")
+	defer span.End()
+
+	var args store.Args
+	where := filterConditions(filter, &args)
+	order, _ := carOrdering(filter.Sort)
+
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id" +
+		" WHERE " + strings.Join(where, " AND ") + " " + store.OrderClause(order)
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(car); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 // CreateCar: insert engine + car dalam SATU transaksi.
@@ -117,7 +300,7 @@ func (s Store) CreateCar(ctx context.Context, carReq *models.CarRequest) (models
 		engineID   uuid.UUID
 	)
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := store.BeginTx(ctx, s.db)
 	if err != nil {
 		return createdCar, err
 	}
@@ -130,17 +313,12 @@ func (s Store) CreateCar(ctx context.Context, carReq *models.CarRequest) (models
 		err = tx.Commit()
 	}()
 
-	// 1) Insert engine dulu, ambil id-nya
-	engineID = uuid.New()
-	_, err = tx.ExecContext(
-		ctx,
-		`INSERT INTO engine (id, displacement, no_of_cylinders, car_range)
-         VALUES ($1, $2, $3, $4)`,
-		engineID, carReq.Engine.Displacement, carReq.Engine.NoOfCylinders, carReq.Engine.CarRange,
-	)
+	// 1) Pakai engine yang sudah ada, atau insert engine baru dan ambil id-nya
+	engine, err := s.resolveEngine(ctx, tx, carReq)
 	if err != nil {
 		return createdCar, err
 	}
+	engineID = engine.EngineID
 
 	// 2) Siapkan objek car
 	carID := uuid.New()
@@ -154,43 +332,277 @@ func (s Store) CreateCar(ctx context.Context, carReq *models.CarRequest) (models
 		Year:      carReq.Year,      // <- penting, sebelumnya terlewat
 		FuelType:  carReq.FuelType,
 		Price:     carReq.Price,
+		Status:    initialStatus(carReq),
 		CreatedAt: now,
 		UpdatedAt: now,
-		Engine: models.Engine{
-			EngineID:      engineID,
-			Displacement:  carReq.Engine.Displacement,
-			NoOfCylinders: carReq.Engine.NoOfCylinders,
-			CarRange:      carReq.Engine.CarRange,
-		},
+		Engine:    engine,
 	}
 
 	// 3) Insert car + RETURNING kolom yang diperlukan
 	err = tx.QueryRowContext(
 		ctx,
-		`INSERT INTO car (id, name, brand, year, fuel_type, engine_id, price, created_at, updated_at)
-         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-         RETURNING id, name, brand, year, fuel_type, price, created_at, updated_at`,
-		newCar.ID, newCar.Name, newCar.Brand, newCar.Year, newCar.FuelType, engineID, newCar.Price, newCar.CreatedAt, newCar.UpdatedAt,
+		`INSERT INTO car (id, name, brand, year, fuel_type, engine_id, price, status, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+         RETURNING id, name, brand, year, fuel_type, price, status, created_at, updated_at, version`,
+		newCar.ID, newCar.Name, newCar.Brand, newCar.Year, newCar.FuelType, engineID, newCar.Price, newCar.Status, newCar.CreatedAt, newCar.UpdatedAt,
 	).Scan(
-		&createdCar.ID, &createdCar.Name, &createdCar.Brand, &createdCar.Year, &createdCar.FuelType, &createdCar.Price, &createdCar.CreatedAt, &createdCar.UpdatedAt,
+		&createdCar.ID, &createdCar.Name, &createdCar.Brand, &createdCar.Year, &createdCar.FuelType, &createdCar.Price, &createdCar.Status, &createdCar.CreatedAt, &createdCar.UpdatedAt, &createdCar.Version,
 	)
 	if err != nil {
 		return createdCar, err
 	}
 
+	// 4) Catat versi pertama ke riwayat
+	if err = store.SnapshotCars(ctx, tx, "id = $1", carID); err != nil {
+		return createdCar, err
+	}
+
 	// (opsional) ikutkan engine agar caller dapat paket lengkap
 	createdCar.Engine = newCar.Engine
 
 	return createdCar, nil
 }
 
-func (s Store) UpdateCar(ctx context.Context, carID string, carReq *models.CarRequest) (models.Car, error) {
+// CreateCars inserts cars and their engines with one multi-row INSERT per
+// table. Callers keep batches to a few hundred rows; PostgreSQL allows at
+// most 65535 parameters per statement.
+func (s Store) CreateCars(ctx context.Context, carReqs []models.CarRequest) ([]models.Car, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "CreateCars-Store")
+	defer span.End()
+
+	if len(carReqs) == 0 {
+		return nil, nil
+	}
+
+	tx, err := store.BeginTx(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	var (
+		now        = time.Now()
+		cars       = make([]models.Car, len(carReqs))
+		carIDs     = make([]string, len(carReqs))
+		engineIDs  []string
+		engineArgs store.Args
+		carArgs    store.Args
+		engineRows []string
+		carRows    []string
+	)
+	shared, err := s.sharedEngines(ctx, tx, carReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, carReq := range carReqs {
+		car := models.Car{
+			ID:        uuid.New(),
+			Name:      carReq.Name,
+			Brand:     carReq.Brand,
+			Year:      carReq.Year,
+			FuelType:  carReq.FuelType,
+			Price:     carReq.Price,
+			Status:    initialStatus(&carReq),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Version:   1,
+			Engine:    engineFromRequest(uuid.New(), carReq.Engine),
+		}
+		if carReq.EngineID != "" {
+			car.Engine = shared[carReq.EngineID]
+		} else {
+			engineIDs = append(engineIDs, car.Engine.EngineID.String())
+			engineRows = append(engineRows, "("+strings.Join([]string{
+				engineArgs.Add(car.Engine.EngineID), engineArgs.Add(car.Engine.Displacement),
+				engineArgs.Add(car.Engine.NoOfCylinders), engineArgs.Add(car.Engine.CarRange),
+				engineArgs.Add(car.Engine.Horsepower), engineArgs.Add(car.Engine.Torque),
+				engineArgs.Add(car.Engine.Transmission), engineArgs.Add(car.Engine.BatteryKWh),
+				engineArgs.Add(car.Engine.ChargingStandard), engineArgs.Add(car.Engine.EmissionsClass),
+			}, ", ")+")")
+		}
+		cars[i] = car
+		carIDs[i] = car.ID.String()
+		carRows = append(carRows, "("+strings.Join([]string{
+			carArgs.Add(car.ID), carArgs.Add(car.Name), carArgs.Add(car.Brand), carArgs.Add(car.Year),
+			carArgs.Add(car.FuelType), carArgs.Add(car.Engine.EngineID), carArgs.Add(car.Price),
+			carArgs.Add(car.Status), carArgs.Add(car.CreatedAt), carArgs.Add(car.UpdatedAt),
+		}, ", ")+")")
+	}
+
+	if len(engineRows) > 0 {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO engine ("+engineInsertColumns+") VALUES "+strings.Join(engineRows, ", "),
+			engineArgs...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err = store.SnapshotEngines(ctx, tx, "id = ANY($1::uuid[])", pq.Array(engineIDs)); err != nil {
+			return nil, err
+		}
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO car (id, name, brand, year, fuel_type, engine_id, price, status, created_at, updated_at) VALUES "+strings.Join(carRows, ", "),
+		carArgs...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = store.SnapshotCars(ctx, tx, "id = ANY($1::uuid[])", pq.Array(carIDs)); err != nil {
+		return nil, err
+	}
+
+	return cars, nil
+}
+
+// initialStatus is the status a new car starts in.
+func initialStatus(carReq *models.CarRequest) string {
+	if carReq.Status == "" {
+		return models.StatusAvailable
+	}
+	return carReq.Status
+}
+
+// engineInsertColumns are the engine columns written when an engine is
+// created, in the order of engineFromRequest's fields.
+const engineInsertColumns = "id, displacement, no_of_cylinders, car_range, horsepower, torque, transmission, battery_kwh, charging_standard, emissions_class"
+
+func engineFromRequest(id uuid.UUID, req models.EngineRequest) models.Engine {
+	return models.Engine{
+		EngineID:         id,
+		Displacement:     req.Displacement,
+		NoOfCylinders:    req.NoOfCylinders,
+		CarRange:         req.CarRange,
+		Horsepower:       req.Horsepower,
+		Torque:           req.Torque,
+		Transmission:     req.Transmission,
+		BatteryKWh:       req.BatteryKWh,
+		ChargingStandard: req.ChargingStandard,
+		EmissionsClass:   req.EmissionsClass,
+		Version:          1,
+	}
+}
+
+// errUnknownEngine reports an engine_id that does not name a live engine.
+var errUnknownEngine = models.Validation("engine_id does not reference an existing engine",
+	models.FieldError{Field: "engine_id", Rule: "exists", Message: "must reference an existing engine"})
+
+// lockEngine reads a live engine and holds a share lock on it, so it cannot
+// be deleted before the car referencing it is saved.
+func lockEngine(ctx context.Context, tx *store.Tx, id string) (models.Engine, error) {
+	var engine models.Engine
+	err := tx.QueryRowContext(ctx,
+		"SELECT "+engineInsertColumns+", version FROM engine WHERE id = $1 AND deleted_at IS NULL FOR SHARE", id,
+	).Scan(
+		&engine.EngineID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange, &engine.Horsepower, &engine.Torque,
+		&engine.Transmission, &engine.BatteryKWh, &engine.ChargingStandard, &engine.EmissionsClass, &engine.Version,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Engine{}, errUnknownEngine
+	}
+	return engine, err
+}
+
+// lockEngineFor locks the engine referenced by carReq.EngineID and checks it
+// suits the car's fuel type.
+func lockEngineFor(ctx context.Context, tx *store.Tx, carReq *models.CarRequest) (models.Engine, error) {
+	engine, err := lockEngine(ctx, tx, carReq.EngineID)
+	if err != nil {
+		return models.Engine{}, err
+	}
+	if err := checkEngineFuel(ctx, tx, engine, carReq.FuelType); err != nil {
+		return models.Engine{}, err
+	}
+	return engine, nil
+}
+
+// checkEngineFuel checks that engine suits fuelType. An engine a live car of
+// that fuel type already uses is accepted as it is, so engines stored before
+// the fuel-aware rules existed can still be shared.
+func checkEngineFuel(ctx context.Context, tx *store.Tx, engine models.Engine, fuelType string) error {
+	var inUse bool
+	err := tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM car WHERE engine_id = $1 AND fuel_type = $2 AND deleted_at IS NULL)",
+		engine.EngineID, fuelType,
+	).Scan(&inUse)
+	if err != nil || inUse {
+		return err
+	}
+	return models.ValidateFuelEngine("engine", fuelType, engine.Request())
+}
+
+// resolveEngine returns the engine referenced by carReq.EngineID, or inserts
+// the inline engine as a new row.
+func (s Store) resolveEngine(ctx context.Context, tx *store.Tx, carReq *models.CarRequest) (models.Engine, error) {
+	if carReq.EngineID != "" {
+		return lockEngineFor(ctx, tx, carReq)
+	}
+
+	engine := engineFromRequest(uuid.New(), carReq.Engine)
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO engine (`+engineInsertColumns+`)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		engine.EngineID, engine.Displacement, engine.NoOfCylinders, engine.CarRange, engine.Horsepower, engine.Torque,
+		engine.Transmission, engine.BatteryKWh, engine.ChargingStandard, engine.EmissionsClass,
+	)
+	if err != nil {
+		return models.Engine{}, err
+	}
+	if err = store.SnapshotEngines(ctx, tx, "id = $1", engine.EngineID); err != nil {
+		return models.Engine{}, err
+	}
+	return engine, nil
+}
+
+// sharedEngines locks every existing engine referenced by carReqs, keyed by
+// the EngineID as given in the request.
+func (s Store) sharedEngines(ctx context.Context, tx *store.Tx, carReqs []models.CarRequest) (map[string]models.Engine, error) {
+	engines := map[string]models.Engine{}
+	for _, carReq := range carReqs {
+		if carReq.EngineID == "" {
+			continue
+		}
+		if _, ok := engines[carReq.EngineID]; ok {
+			continue
+		}
+		engine, err := lockEngine(ctx, tx, carReq.EngineID)
+		if err != nil {
+			return nil, err
+		}
+		engines[carReq.EngineID] = engine
+	}
+	for _, carReq := range carReqs {
+		if carReq.EngineID == "" {
+			continue
+		}
+		if err := checkEngineFuel(ctx, tx, engines[carReq.EngineID], carReq.FuelType); err != nil {
+			return nil, err
+		}
+	}
+	return engines, nil
+}
+
+// UpdateCar replaces the car when the current version is accepted by match.
+// With EngineID the car is moved to that engine. An inline engine that
+// differs from the current one is written in place when only this car uses
+// it, and otherwise saved as a new engine so other cars are not changed.
+func (s Store) UpdateCar(ctx context.Context, carID string, carReq *models.CarRequest, match models.VersionMatch) (models.Car, error) {
 	tracer := otel.Tracer("CarStore")
 	ctx, span := tracer.Start(ctx, "UpdateCar-Store")
 	defer span.End()
 	var updatedCar models.Car
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := store.BeginTx(ctx, s.db)
 	if err != nil {
 		return models.Car{}, err
 	}
@@ -202,29 +614,30 @@ func (s Store) UpdateCar(ctx context.Context, carID string, carReq *models.CarRe
 		err = tx.Commit()
 	}()
 
-	// 1. Get current car data to find engine_id
-	var engineID uuid.UUID
+	// 1. Get current car data to find engine_id; lock the row so the version
+	// check and the update are atomic.
+	var (
+		engineID uuid.UUID
+		version  int
+	)
 	err = tx.QueryRowContext(
 		ctx,
-		`SELECT engine_id FROM car WHERE id = $1`,
+		`SELECT engine_id, version FROM car WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
 		carID,
-	).Scan(&engineID)
+	).Scan(&engineID, &version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return models.Car{}, errors.New("car not found")
+			return models.Car{}, errCarNotFound
 		}
 		return models.Car{}, err
 	}
+	if err = match.CheckVersion("car", version); err != nil {
+		return models.Car{}, err
+	}
 
-	// 2. Update engine data
-	_, err = tx.ExecContext(
-		ctx,
-		`UPDATE engine
-         SET displacement = $1, no_of_cylinders = $2, car_range = $3, updated_at = $4
-         WHERE id = $5`,
-		carReq.Engine.Displacement, carReq.Engine.NoOfCylinders, carReq.Engine.CarRange, time.Now(), engineID,
-	)
-	if err != nil {
+	// 2. Pick the engine: a referenced one, the current one (updated in place
+	// when not shared) or a new copy.
+	if engineID, err = s.updateEngine(ctx, tx, carID, engineID, carReq); err != nil {
 		return models.Car{}, err
 	}
 
@@ -232,27 +645,22 @@ func (s Store) UpdateCar(ctx context.Context, carID string, carReq *models.CarRe
 	_, err = tx.ExecContext(
 		ctx,
 		`UPDATE car
-         SET name = $1, brand = $2, year = $3, fuel_type = $4, price = $5, updated_at = $6
-         WHERE id = $7`,
-		carReq.Name, carReq.Brand, carReq.Year, carReq.FuelType, carReq.Price, time.Now(), carID,
+         SET name = $1, brand = $2, year = $3, fuel_type = $4, price = $5, engine_id = $6, updated_at = $7, version = version + 1
+         WHERE id = $8`,
+		carReq.Name, carReq.Brand, carReq.Year, carReq.FuelType, carReq.Price, engineID, time.Now(), carID,
 	)
 	if err != nil {
 		return models.Car{}, err
 	}
 
-	// 4. Get the complete updated car data with engine
-	query := `
-SELECT
-  c.id, c.name, c.brand, c.year, c.fuel_type, c.price, c.created_at, c.updated_at,
-  e.id, e.displacement, e.no_of_cylinders, e.car_range
-FROM car c
-LEFT JOIN engine e ON c.engine_id = e.id
-WHERE c.id = $1
-`
-	err = tx.QueryRowContext(ctx, query, carID).Scan(
-		&updatedCar.ID, &updatedCar.Name, &updatedCar.Brand, &updatedCar.Year, &updatedCar.FuelType, &updatedCar.Price, &updatedCar.CreatedAt, &updatedCar.UpdatedAt,
-		&updatedCar.Engine.EngineID, &updatedCar.Engine.Displacement, &updatedCar.Engine.NoOfCylinders, &updatedCar.Engine.CarRange,
-	)
+	// 4. Record the new version in history
+	if err = store.SnapshotCars(ctx, tx, "id = $1", carID); err != nil {
+		return models.Car{}, err
+	}
+
+	// 5. Get the complete updated car data with engine
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1"
+	updatedCar, err = scanCar(tx.QueryRowContext(ctx, query, carID))
 	if err != nil {
 		return models.Car{}, err
 	}
@@ -260,14 +668,65 @@ WHERE c.id = $1
 	return updatedCar, nil
 }
 
-func (s Store) DeleteCar(ctx context.Context, carID string) (models.Car, error) {
+// updateEngine applies the engine part of carReq for the car and returns the
+// engine the car should point at.
+func (s Store) updateEngine(ctx context.Context, tx *store.Tx, carID string, engineID uuid.UUID, carReq *models.CarRequest) (uuid.UUID, error) {
+	if carReq.EngineID != "" {
+		engine, err := lockEngineFor(ctx, tx, carReq)
+		return engine.EngineID, err
+	}
+
+	var (
+		current models.EngineRequest
+		users   int
+	)
+	err := tx.QueryRowContext(ctx,
+		`SELECT displacement, no_of_cylinders, car_range, horsepower, torque, transmission, battery_kwh, charging_standard, emissions_class,
+                (SELECT COUNT(*) FROM car WHERE engine_id = e.id AND id <> $2)
+         FROM engine e WHERE e.id = $1 FOR UPDATE`,
+		engineID, carID,
+	).Scan(
+		&current.Displacement, &current.NoOfCylinders, &current.CarRange, &current.Horsepower, &current.Torque, &current.Transmission,
+		&current.BatteryKWh, &current.ChargingStandard, &current.EmissionsClass, &users,
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if current == carReq.Engine {
+		return engineID, nil
+	}
+
+	if users > 0 {
+		engine, err := s.resolveEngine(ctx, tx, carReq)
+		return engine.EngineID, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE engine
+         SET displacement = $1, no_of_cylinders = $2, car_range = $3, horsepower = $4, torque = $5, transmission = $6,
+             battery_kwh = $7, charging_standard = $8, emissions_class = $9, updated_at = $10, version = version + 1
+         WHERE id = $11`,
+		carReq.Engine.Displacement, carReq.Engine.NoOfCylinders, carReq.Engine.CarRange, carReq.Engine.Horsepower, carReq.Engine.Torque,
+		carReq.Engine.Transmission, carReq.Engine.BatteryKWh, carReq.Engine.ChargingStandard, carReq.Engine.EmissionsClass, time.Now(), engineID,
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return engineID, store.SnapshotEngines(ctx, tx, "id = $1", engineID)
+}
+
+// DeleteCar moves the car to the trash. The row stays until PurgeDeleted
+// removes it, so it can be restored in the meantime. Cars with an open order
+// are refused.
+func (s Store) DeleteCar(ctx context.Context, carID, deletedBy string, match models.VersionMatch) (models.Car, error) {
 	tracer := otel.Tracer("CarStore")
 	ctx, span := tracer.Start(ctx, "DeleteCar-Store")
 	defer span.End()
 
 	var deletedCar models.Car
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := store.BeginTx(ctx, s.db)
 	if err != nil {
 		return models.Car{}, err
 	}
@@ -280,33 +739,146 @@ func (s Store) DeleteCar(ctx context.Context, carID string) (models.Car, error)
 	}()
 
 	// Ambil dulu datanya untuk dikembalikan ke caller
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1 AND c.deleted_at IS NULL FOR UPDATE OF c"
+	deletedCar, err = scanCar(tx.QueryRowContext(ctx, query, carID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Car{}, errCarNotFound
+		}
+		return models.Car{}, err
+	}
+	if err = match.CheckVersion("car", deletedCar.Version); err != nil {
+		return models.Car{}, err
+	}
+
+	// Mobil yang masih punya order terbuka sedang dijual; batalkan dulu
+	// ordernya.
+	var ordered bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM sales_order WHERE car_id = $1 AND status = 'open')", carID).Scan(&ordered)
+	if err != nil {
+		return models.Car{}, err
+	}
+	if ordered {
+		err = errCarOrdered
+		return models.Car{}, err
+	}
+
+	// Tandai sebagai terhapus
+	var deletedAt time.Time
 	err = tx.QueryRowContext(
 		ctx,
-		`SELECT id, name, brand, year, fuel_type, price, created_at, updated_at
-         FROM car WHERE id = $1`,
-		carID,
-	).Scan(
-		&deletedCar.ID, &deletedCar.Name, &deletedCar.Brand, &deletedCar.Year, &deletedCar.FuelType, &deletedCar.Price, &deletedCar.CreatedAt, &deletedCar.UpdatedAt,
+		`UPDATE car SET deleted_at = NOW(), deleted_by = $2, version = version + 1
+         WHERE id = $1 RETURNING deleted_at, version`,
+		carID, deletedBy,
+	).Scan(&deletedAt, &deletedCar.Version)
+	if err != nil {
+		return models.Car{}, err
+	}
+	if err = store.SnapshotCars(ctx, tx, "id = $1", carID); err != nil {
+		return models.Car{}, err
+	}
+	deletedCar.DeletedAt = &deletedAt
+	deletedCar.DeletedBy = deletedBy
+
+	return deletedCar, nil
+}
+
+// RestoreCar takes the car out of the trash, together with its engine if the
+// engine was deleted with or after the car. It fails with a Conflict when
+// the engine was deleted before the car.
+func (s Store) RestoreCar(ctx context.Context, carID string) (models.Car, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "RestoreCar-Store")
+	defer span.End()
+
+	tx, err := store.BeginTx(ctx, s.db)
+	if err != nil {
+		return models.Car{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	var (
+		engineID  uuid.UUID
+		deletedAt time.Time
 	)
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT engine_id, deleted_at FROM car WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE",
+		carID,
+	).Scan(&engineID, &deletedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return models.Car{}, errors.New("car not found")
+			err = models.NotFound("car not found in trash")
 		}
 		return models.Car{}, err
 	}
 
-	// Hapus barisnya
-	result, err := tx.ExecContext(ctx, "DELETE FROM car WHERE id = $1", carID)
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE car SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = $1",
+		carID,
+	)
 	if err != nil {
 		return models.Car{}, err
 	}
-	affected, err := result.RowsAffected()
+
+	// An engine deleted with or after the car comes back with it. One
+	// deleted before the car was trashed on its own and must be restored
+	// first.
+	var engineDeletedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT deleted_at FROM engine WHERE id = $1 FOR UPDATE", engineID).Scan(&engineDeletedAt)
 	if err != nil {
 		return models.Car{}, err
 	}
-	if affected == 0 {
-		return models.Car{}, errors.New("car not found")
+	if engineDeletedAt.Valid && engineDeletedAt.Time.Before(deletedAt) {
+		err = models.Conflict("car's engine was deleted before the car; restore the engine first")
+		return models.Car{}, err
+	}
+	if engineDeletedAt.Valid {
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE engine SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = $1",
+			engineID,
+		)
+		if err != nil {
+			return models.Car{}, err
+		}
+	}
+
+	if err = store.SnapshotEngines(ctx, tx, "id = $1", engineID); err != nil {
+		return models.Car{}, err
+	}
+	if err = store.SnapshotCars(ctx, tx, "id = $1", carID); err != nil {
+		return models.Car{}, err
 	}
 
-	return deletedCar, nil
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1"
+	restoredCar, err := scanCar(tx.QueryRowContext(ctx, query, carID))
+	if err != nil {
+		return models.Car{}, err
+	}
+	return restoredCar, nil
+}
+
+// PurgeDeleted permanently removes cars that were deleted before cutoff.
+func (s Store) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "PurgeDeleted-Store")
+	defer span.End()
+
+	// Cars on a sales order stay in the trash: the order keeps a reference.
+	result, err := store.Conn(ctx, s.db).ExecContext(ctx,
+		"DELETE FROM car WHERE deleted_at < $1 AND NOT EXISTS (SELECT 1 FROM sales_order o WHERE o.car_id = car.id)",
+		cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
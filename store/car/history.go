@@ -0,0 +1,139 @@
+package car
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// revisionQuery reads car_history rows joined with the engine version that
+// was current when each car version was written.
+const revisionQuery = `SELECT h.version, h.valid_from,
+  h.car_id, h.name, h.brand, h.year, h.fuel_type, h.price, h.status, c.created_at, h.deleted_at, h.deleted_by,
+  eh.engine_id, eh.displacement, eh.no_of_cylinders, eh.car_range, eh.horsepower, eh.torque, eh.transmission,
+  eh.battery_kwh, eh.charging_standard, eh.emissions_class, eh.version
+FROM car_history h
+JOIN car c ON c.id = h.car_id
+LEFT JOIN LATERAL (
+  SELECT x.engine_id, x.displacement, x.no_of_cylinders, x.car_range, x.horsepower, x.torque, x.transmission,
+    x.battery_kwh, x.charging_standard, x.emissions_class, x.version
+  FROM engine_history x
+  WHERE x.engine_id = h.engine_id AND x.valid_from <= h.valid_from
+  ORDER BY x.valid_from DESC, x.version DESC
+  LIMIT 1
+) eh ON TRUE`
+
+func scanRevision(row scanner) (models.CarRevision, error) {
+	var (
+		rev       models.CarRevision
+		deletedAt sql.NullTime
+		deletedBy sql.NullString
+		engineID  uuid.NullUUID
+		disp      sql.NullInt64
+		cylinders sql.NullInt64
+		carRange  sql.NullInt64
+		power     sql.NullInt64
+		torque    sql.NullInt64
+		trans     sql.NullString
+		battery   sql.NullFloat64
+		charging  sql.NullString
+		emissions sql.NullString
+		engineVer sql.NullInt64
+	)
+	car := &rev.Car
+	err := row.Scan(
+		&rev.Version, &rev.ValidFrom,
+		&car.ID, &car.Name, &car.Brand, &car.Year, &car.FuelType, &car.Price, &car.Status, &car.CreatedAt, &deletedAt, &deletedBy,
+		&engineID, &disp, &cylinders, &carRange, &power, &torque, &trans, &battery, &charging, &emissions, &engineVer,
+	)
+	if err != nil {
+		return models.CarRevision{}, err
+	}
+
+	car.Version, car.UpdatedAt = rev.Version, rev.ValidFrom
+	if deletedAt.Valid {
+		car.DeletedAt = &deletedAt.Time
+	}
+	car.DeletedBy = deletedBy.String
+	car.Engine = models.Engine{
+		EngineID:         engineID.UUID,
+		Displacement:     disp.Int64,
+		NoOfCylinders:    int(cylinders.Int64),
+		CarRange:         carRange.Int64,
+		Horsepower:       int(power.Int64),
+		Torque:           int(torque.Int64),
+		Transmission:     trans.String,
+		BatteryKWh:       battery.Float64,
+		ChargingStandard: charging.String,
+		EmissionsClass:   emissions.String,
+		Version:          int(engineVer.Int64),
+	}
+	return rev, nil
+}
+
+// ListCarHistory returns every stored version of the car, newest first.
+func (s Store) ListCarHistory(ctx context.Context, id string) ([]models.CarRevision, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "ListCarHistory-Store")
+	defer span.End()
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, revisionQuery+" WHERE h.car_id = $1 ORDER BY h.version DESC", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.CarRevision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, errCarNotFound
+	}
+	return revisions, nil
+}
+
+func (s Store) GetCarRevision(ctx context.Context, id string, version int) (models.CarRevision, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "GetCarRevision-Store")
+	defer span.End()
+
+	rev, err := scanRevision(store.Conn(ctx, s.db).QueryRowContext(ctx, revisionQuery+" WHERE h.car_id = $1 AND h.version = $2", id, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CarRevision{}, models.NotFound("car version not found")
+		}
+		return models.CarRevision{}, err
+	}
+	return rev, nil
+}
+
+// GetCarAsOf returns the version of the car that was current at t.
+func (s Store) GetCarAsOf(ctx context.Context, id string, t time.Time) (models.CarRevision, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "GetCarAsOf-Store")
+	defer span.End()
+
+	query := revisionQuery + " WHERE h.car_id = $1 AND h.valid_from <= $2 ORDER BY h.version DESC LIMIT 1"
+	rev, err := scanRevision(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id, t))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CarRevision{}, models.NotFound("car did not exist at that time")
+		}
+		return models.CarRevision{}, err
+	}
+	return rev, nil
+}
@@ -0,0 +1,165 @@
+package car
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// brandSimilarity is the trigram similarity a term needs to be read as
+	// a misspelled brand; "toyta" scores 0.44 against "toyota".
+	brandSimilarity = 0.4
+	// nameSimilarity lets a query match a car name it does not spell
+	// exactly, e.g. "civc" finds "Honda Civic".
+	nameSimilarity = 0.5
+)
+
+// markStart and markStop delimit matches in the raw ts_headline output.
+// They are control characters, which highlight replaces with <mark> tags
+// once the car's text has been HTML-escaped; any already in the text are
+// stripped before highlighting.
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+// searchQuery ranks live cars against a prefix tsquery ($1) and the plain
+// query text ($2) for trigram matching. The filters use the @@ and <%
+// operators, which the GIN indexes on search_vector and lower(name) serve;
+// <% reads pg_trgm.word_similarity_threshold.
+const searchQuery = `SELECT ` + carColumns + `,
+  ts_rank_cd(c.search_vector, to_tsquery('simple', $1)) + word_similarity($2, lower(c.name)) AS rank,
+  ts_headline('simple', translate(c.brand || ' ' || c.name || ' ' || c.year || ' ' || c.fuel_type, '` + markStart + markStop + `', ''),
+    to_tsquery('simple', $1), 'StartSel=` + markStart + `, StopSel=` + markStop + `, HighlightAll=true') AS snippet
+FROM car c
+LEFT JOIN engine e ON c.engine_id = e.id
+WHERE c.deleted_at IS NULL
+  AND (c.search_vector @@ to_tsquery('simple', $1) OR $2::text <% lower(c.name))
+ORDER BY rank DESC, c.id
+LIMIT $3`
+
+// setTrigramThreshold sets a pg_trgm threshold until the transaction ends.
+// The indexable % and <% operators compare against it instead of taking a
+// threshold argument.
+func setTrigramThreshold(ctx context.Context, tx *store.Tx, name string, value float64) error {
+	_, err := tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", name, strconv.FormatFloat(value, 'f', -1, 64))
+	return err
+}
+
+// extraScanner scans the carColumns with scanCar and the remaining columns
+// of the row into extra.
+type extraScanner struct {
+	row   scanner
+	extra []any
+}
+
+func (s extraScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// SearchCars returns live cars matching any term as a word prefix, or whose
+// name is close to the query, best match first. Cars matching more of the
+// terms rank higher, so "civic 2023 hybrid" still finds a 2023 petrol Civic.
+func (s Store) SearchCars(ctx context.Context, search models.CarSearch) ([]models.CarSearchResult, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "SearchCars-Store")
+	defer span.End()
+
+	prefixes := make([]string, len(search.Terms))
+	for i, t := range search.Terms {
+		prefixes[i] = t + ":*"
+	}
+
+	limit := search.Limit
+	if limit <= 0 || limit > models.MaxPageSize {
+		limit = models.DefaultSearchLimit
+	}
+
+	tx, err := store.BeginTx(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := setTrigramThreshold(ctx, tx, "pg_trgm.word_similarity_threshold", nameSimilarity); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, searchQuery, strings.Join(prefixes, " | "), strings.Join(search.Terms, " "), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.CarSearchResult{}
+	for rows.Next() {
+		var r models.CarSearchResult
+		if r.Car, err = scanCar(extraScanner{row: rows, extra: []any{&r.Rank, &r.Snippet}}); err != nil {
+			return nil, err
+		}
+		r.Snippet = highlight(r.Snippet)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// highlight turns a ts_headline snippet into HTML: the car's text is
+// escaped, so a name such as "<img ...>" stays text, and only the match
+// delimiters become <mark> tags.
+func highlight(snippet string) string {
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// SuggestBrands maps each term that matches no car to the most similar
+// brand in the catalogue, if one is close enough. Terms that already match
+// are left out.
+func (s Store) SuggestBrands(ctx context.Context, terms []string) (map[string]string, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "SuggestBrands-Store")
+	defer span.End()
+
+	tx, err := store.BeginTx(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// % reads pg_trgm.similarity_threshold and can use idx_car_brand_trgm.
+	if err := setTrigramThreshold(ctx, tx, "pg_trgm.similarity_threshold", brandSimilarity); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx,
+		`SELECT t.term, b.brand
+         FROM unnest($1::text[]) AS t(term)
+         CROSS JOIN LATERAL (
+           SELECT c.brand FROM car c
+           WHERE c.deleted_at IS NULL AND lower(c.brand) % t.term
+           ORDER BY similarity(lower(c.brand), t.term) DESC, c.brand
+           LIMIT 1
+         ) b
+         WHERE NOT EXISTS (
+           SELECT 1 FROM car c WHERE c.deleted_at IS NULL AND c.search_vector @@ to_tsquery('simple', t.term || ':*')
+         )`,
+		pq.Array(terms),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := map[string]string{}
+	for rows.Next() {
+		var term, brand string
+		if err := rows.Scan(&term, &brand); err != nil {
+			return nil, err
+		}
+		suggestions[term] = brand
+	}
+	return suggestions, rows.Err()
+}
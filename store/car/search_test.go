@@ -0,0 +1,37 @@
+package car
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name, snippet, want string
+	}{
+		{
+			name:    "matches become mark tags",
+			snippet: "Honda " + markStart + "Civic" + markStop + " 2023 Petrol",
+			want:    "Honda <mark>Civic</mark> 2023 Petrol",
+		},
+		{
+			name:    "markup in the car's text is escaped",
+			snippet: "Evil " + markStart + "img" + markStop + ` <img src=x onerror="alert(1)"> 2023 Petrol`,
+			want:    "Evil <mark>img</mark> &lt;img src=x onerror=&#34;alert(1)&#34;&gt; 2023 Petrol",
+		},
+		{
+			name:    "ampersands inside a match are escaped",
+			snippet: markStart + "A&B" + markStop + " Motors <1> 2020 Diesel",
+			want:    "<mark>A&amp;B</mark> Motors &lt;1&gt; 2020 Diesel",
+		},
+		{
+			name:    "no matches",
+			snippet: "Tom's & Jerry's 2020 Electric",
+			want:    "Tom&#39;s &amp; Jerry&#39;s 2020 Electric",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.snippet); got != tt.want {
+				t.Errorf("highlight(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package car
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+// SetCarStatus moves a live car from one status to another and records the
+// transition. It fails with a Conflict when the car is no longer in from,
+// so a concurrent transition cannot be overwritten.
+func (s Store) SetCarStatus(ctx context.Context, carID string, t models.Transition, from, changedBy, note string) (models.Car, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "SetCarStatus-Store")
+	defer span.End()
+
+	tx, err := store.BeginTx(ctx, s.db)
+	if err != nil {
+		return models.Car{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE car SET status = $3, status_changed_at = NOW(), status_changed_by = $4, updated_at = NOW(), version = version + 1
+         WHERE id = $1 AND status = $2 AND deleted_at IS NULL`,
+		carID, from, t.To, changedBy,
+	)
+	if err != nil {
+		return models.Car{}, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err = models.Conflict("car status has changed; fetch it again and retry")
+		return models.Car{}, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO car_status_change (car_id, action, from_status, to_status, changed_by, note)
+         VALUES ($1, $2, $3, $4, $5, $6)`,
+		carID, t.Action, from, t.To, changedBy, note,
+	)
+	if err != nil {
+		return models.Car{}, err
+	}
+	// Leaving reserved ends the car's hold: selling it fulfils the hold,
+	// anything else releases it.
+	if from == models.StatusReserved {
+		outcome := models.ReservationReleased
+		if t.To == models.StatusSold {
+			outcome = models.ReservationFulfilled
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE reservation SET status = $2, closed_at = NOW(), closed_by = $3
+             WHERE car_id = $1 AND status = 'active'`,
+			carID, outcome, changedBy,
+		)
+		if err != nil {
+			return models.Car{}, err
+		}
+	}
+	// A sold or withdrawn car can no longer be test driven.
+	if t.To == models.StatusSold || t.To == models.StatusWithdrawn {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE test_drive SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $2,
+                 updated_at = NOW(), version = version + 1
+             WHERE car_id = $1 AND status = 'scheduled' AND starts_at > NOW()`,
+			carID, changedBy,
+		)
+		if err != nil {
+			return models.Car{}, err
+		}
+	}
+
+	if err = store.SnapshotCars(ctx, tx, "id = $1", carID); err != nil {
+		return models.Car{}, err
+	}
+
+	query := "SELECT " + carColumns + " FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1"
+	car, err := scanCar(tx.QueryRowContext(ctx, query, carID))
+	if err != nil {
+		return models.Car{}, err
+	}
+	return car, nil
+}
+
+// ListStatusChanges returns the status transitions of a car, oldest first.
+func (s Store) ListStatusChanges(ctx context.Context, carID string) ([]models.CarStatusChange, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "ListStatusChanges-Store")
+	defer span.End()
+
+	var exists bool
+	err := store.Conn(ctx, s.db).QueryRowContext(ctx, "SELECT TRUE FROM car WHERE id = $1", carID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errCarNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx,
+		`SELECT id, car_id, action, from_status, to_status, changed_by, note, changed_at
+         FROM car_status_change WHERE car_id = $1 ORDER BY changed_at, id`,
+		carID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.CarStatusChange{}
+	for rows.Next() {
+		var c models.CarStatusChange
+		if err := rows.Scan(&c.ID, &c.CarID, &c.Action, &c.From, &c.To, &c.ChangedBy, &c.Note, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
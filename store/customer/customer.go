@@ -0,0 +1,313 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	errCustomerNotFound = models.NotFound("customer not found")
+	errUnknownCar       = models.Validation("interested cars must exist",
+		models.FieldError{Field: "interested_cars", Rule: "exists", Message: "one or more cars do not exist"})
+)
+
+const customerColumns = `c.id, c.name, c.email, c.phone, c.address, c.consent_email, c.consent_sms, c.consent_phone,
+       c.consent_updated_at, COALESCE((SELECT array_agg(i.car_id::text ORDER BY i.created_at, i.car_id)
+                                       FROM customer_interest i WHERE i.customer_id = c.id), '{}'),
+       c.created_by, c.created_at, c.updated_at, c.version`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCustomer(row scanner) (models.Customer, error) {
+	var (
+		c                models.Customer
+		consentUpdatedAt sql.NullTime
+	)
+	err := row.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Address, &c.Consent.Email, &c.Consent.SMS, &c.Consent.Phone,
+		&consentUpdatedAt, pq.Array(&c.InterestedCars), &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt, &c.Version)
+	if consentUpdatedAt.Valid {
+		c.ConsentUpdatedAt = &consentUpdatedAt.Time
+	}
+	return c, err
+}
+
+type CustomerStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *CustomerStore {
+	return &CustomerStore{db: db}
+}
+
+func (s CustomerStore) GetCustomer(ctx context.Context, id string) (models.Customer, error) {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "GetCustomer-Store")
+	defer span.End()
+
+	query := "SELECT " + customerColumns + " FROM customer c WHERE c.id = $1"
+	c, err := scanCustomer(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Customer{}, errCustomerNotFound
+	}
+	return c, err
+}
+
+// GetCustomerForUpdate reads a customer and locks its row until the
+// surrounding transaction ends.
+func (s CustomerStore) GetCustomerForUpdate(ctx context.Context, id string) (models.Customer, error) {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "GetCustomerForUpdate-Store")
+	defer span.End()
+
+	query := "SELECT " + customerColumns + " FROM customer c WHERE c.id = $1 FOR UPDATE OF c"
+	c, err := scanCustomer(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Customer{}, errCustomerNotFound
+	}
+	return c, err
+}
+
+// ListCustomers returns one page of customers ordered by name.
+func (s CustomerStore) ListCustomers(ctx context.Context, filter models.CustomerFilter) (models.CustomerPage, error) {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "ListCustomers-Store")
+	defer span.End()
+
+	var (
+		args  store.Args
+		where = []string{"TRUE"}
+	)
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		p := args.Add(store.LikePattern(q))
+		where = append(where, "(c.name ILIKE "+p+" OR c.email ILIKE "+p+" OR c.phone ILIKE "+p+")")
+	}
+	if filter.CarID != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM customer_interest i WHERE i.customer_id = c.id AND i.car_id = "+args.Add(filter.CarID)+")")
+	}
+
+	order := []store.OrderBy{{Column: "c.name", Cast: "text"}, {Column: "c.id", Cast: "uuid"}}
+	if filter.Cursor != "" {
+		cursor, err := store.DecodeCursor(filter.Cursor, len(order))
+		if err != nil {
+			return models.CustomerPage{}, err
+		}
+		where = append(where, store.KeysetCondition(order, cursor, &args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > models.MaxPageSize {
+		limit = models.DefaultPageSize
+	}
+
+	query := "SELECT " + customerColumns + " FROM customer c WHERE " + strings.Join(where, " AND ") + " " +
+		store.OrderClause(order) + " LIMIT " + args.Add(limit+1)
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.CustomerPage{}, err
+	}
+	defer rows.Close()
+
+	page := models.CustomerPage{Customers: []models.Customer{}}
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return models.CustomerPage{}, err
+		}
+		page.Customers = append(page.Customers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return models.CustomerPage{}, err
+	}
+
+	if len(page.Customers) > limit {
+		page.Customers = page.Customers[:limit]
+		last := page.Customers[limit-1]
+		page.NextCursor = store.EncodeCursor([]string{last.Name, last.ID.String()})
+	}
+	return page, nil
+}
+
+func (s CustomerStore) CreateCustomer(ctx context.Context, req *models.CustomerRequest, createdBy string) (models.Customer, error) {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "CreateCustomer-Store")
+	defer span.End()
+
+	tx, err := store.BeginTx(ctx, s.db)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	id := uuid.New()
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO customer (id, name, email, phone, address, consent_email, consent_sms, consent_phone, consent_updated_at, created_by)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $6 OR $7 OR $8 THEN NOW() END, $9)`,
+		id, req.Name, req.Email, req.Phone, req.Address, req.Consent.Email, req.Consent.SMS, req.Consent.Phone, createdBy,
+	)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	if err = setInterests(ctx, tx, id.String(), req.InterestedCars); err != nil {
+		return models.Customer{}, err
+	}
+
+	query := "SELECT " + customerColumns + " FROM customer c WHERE c.id = $1"
+	created, err := scanCustomer(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return models.Customer{}, err
+	}
+	return created, nil
+}
+
+// UpdateCustomer replaces a customer's details and interested cars.
+// consent_updated_at moves only when a consent flag actually changes.
+func (s CustomerStore) UpdateCustomer(ctx context.Context, id string, req *models.CustomerRequest) (models.Customer, error) {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "UpdateCustomer-Store")
+	defer span.End()
+
+	tx, err := store.BeginTx(ctx, s.db)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE customer SET name = $2, email = $3, phone = $4, address = $5,
+             consent_updated_at = CASE WHEN (consent_email, consent_sms, consent_phone) IS DISTINCT FROM ($6, $7, $8)
+                                       THEN NOW() ELSE consent_updated_at END,
+             consent_email = $6, consent_sms = $7, consent_phone = $8,
+             updated_at = NOW(), version = version + 1
+         WHERE id = $1`,
+		id, req.Name, req.Email, req.Phone, req.Address, req.Consent.Email, req.Consent.SMS, req.Consent.Phone,
+	)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err = errCustomerNotFound
+		return models.Customer{}, err
+	}
+	if err = setInterests(ctx, tx, id, req.InterestedCars); err != nil {
+		return models.Customer{}, err
+	}
+
+	query := "SELECT " + customerColumns + " FROM customer c WHERE c.id = $1"
+	updated, err := scanCustomer(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return models.Customer{}, err
+	}
+	return updated, nil
+}
+
+// setInterests replaces the customer's interested cars, keeping when each
+// remaining interest was first recorded.
+func setInterests(ctx context.Context, tx store.DBTX, customerID string, carIDs []string) error {
+	if carIDs == nil {
+		carIDs = []string{}
+	}
+	_, err := tx.ExecContext(ctx,
+		"DELETE FROM customer_interest WHERE customer_id = $1 AND NOT (car_id = ANY($2::uuid[]))",
+		customerID, pq.Array(carIDs),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO customer_interest (customer_id, car_id)
+         SELECT $1, unnest($2::uuid[])
+         ON CONFLICT DO NOTHING`,
+		customerID, pq.Array(carIDs),
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return errUnknownCar
+	}
+	return err
+}
+
+// DeleteCustomer removes a customer with their interests, notes and leads.
+func (s CustomerStore) DeleteCustomer(ctx context.Context, id string) error {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "DeleteCustomer-Store")
+	defer span.End()
+
+	result, err := store.Conn(ctx, s.db).ExecContext(ctx, "DELETE FROM customer WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errCustomerNotFound
+	}
+	return nil
+}
+
+// ListNotes returns a customer's notes, oldest first.
+func (s CustomerStore) ListNotes(ctx context.Context, customerID string) ([]models.CustomerNote, error) {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "ListNotes-Store")
+	defer span.End()
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx,
+		`SELECT id, customer_id, body, author, created_at FROM customer_note
+         WHERE customer_id = $1 ORDER BY created_at, id`,
+		customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.CustomerNote{}
+	for rows.Next() {
+		var n models.CustomerNote
+		if err := rows.Scan(&n.ID, &n.CustomerID, &n.Body, &n.Author, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+func (s CustomerStore) AddNote(ctx context.Context, customerID, body, author string) (models.CustomerNote, error) {
+	tracer := otel.Tracer("CustomerStore")
+	ctx, span := tracer.Start(ctx, "AddNote-Store")
+	defer span.End()
+
+	var n models.CustomerNote
+	err := store.Conn(ctx, s.db).QueryRowContext(ctx,
+		`INSERT INTO customer_note (customer_id, body, author) VALUES ($1, $2, $3)
+         RETURNING id, customer_id, body, author, created_at`,
+		customerID, body, author,
+	).Scan(&n.ID, &n.CustomerID, &n.Body, &n.Author, &n.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return models.CustomerNote{}, errCustomerNotFound
+	}
+	return n, err
+}
@@ -5,12 +5,36 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 )
 
+var (
+	errEngineNotFound = models.NotFound("engine not found")
+	errEngineSold     = models.Conflict("engine is used by a sold car and can no longer be changed")
+)
+
+// engineColumns is the select list read by scanEngine.
+const engineColumns = `id, displacement, no_of_cylinders, car_range, horsepower, torque, transmission,
+  battery_kwh, charging_standard, emissions_class, version`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEngine(row scanner) (models.Engine, error) {
+	var engine models.Engine
+	err := row.Scan(
+		&engine.EngineID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange, &engine.Horsepower, &engine.Torque, &engine.Transmission,
+		&engine.BatteryKWh, &engine.ChargingStandard, &engine.EmissionsClass, &engine.Version,
+	)
+	return engine, err
+}
+
 type EngineStore struct {
 	db *sql.DB
 }
@@ -24,17 +48,30 @@ func (e EngineStore) GetEngineById(ctx context.Context, id string) (models.Engin
 	ctx, span := tracer.Start(ctx, "GetEngineById-Store")
 	defer span.End()
 
-	var engine models.Engine
+	engine, err := scanEngine(store.Conn(ctx, e.db).QueryRowContext(ctx, "SELECT "+engineColumns+" FROM engine WHERE id = $1 AND deleted_at IS NULL", id))
 
-	err := e.db.QueryRowContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range FROM engine WHERE id = $1", id).Scan(
-		&engine.EngineID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange,
-	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Engine{}, errEngineNotFound
+		}
+		return models.Engine{}, err
+	}
+	return engine, nil
+}
+
+// GetEngineForUpdate reads a live engine and locks its row until the
+// surrounding transaction ends.
+func (e EngineStore) GetEngineForUpdate(ctx context.Context, id string) (models.Engine, error) {
+	tracer := otel.Tracer("EngineStore")
+	ctx, span := tracer.Start(ctx, "GetEngineForUpdate-Store")
+	defer span.End()
 
+	engine, err := scanEngine(store.Conn(ctx, e.db).QueryRowContext(ctx, "SELECT "+engineColumns+" FROM engine WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return engine, nil
+			return models.Engine{}, errEngineNotFound
 		}
-		return engine, err
+		return models.Engine{}, err
 	}
 	return engine, nil
 }
@@ -44,7 +81,7 @@ func (e EngineStore) EngineCreate(ctx context.Context, engineReq *models.EngineR
 	ctx, span := tracer.Start(ctx, "EngineCreate-Store")
 	defer span.End()
 
-	tx, err := e.db.BeginTx(ctx, nil)
+	tx, err := store.BeginTx(ctx, e.db)
 	if err != nil {
 		return models.Engine{}, err
 	}
@@ -64,34 +101,39 @@ func (e EngineStore) EngineCreate(ctx context.Context, engineReq *models.EngineR
 
 	engineID := uuid.New()
 
-	_, err = tx.ExecContext(ctx, "INSERT INTO engine (id, displacement, no_of_cylinders, car_range) VALUES ($1, $2, $3, $4)",
-		engineID, engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange)
-		
+	_, err = tx.ExecContext(ctx, `INSERT INTO engine (id, displacement, no_of_cylinders, car_range, horsepower, torque, transmission, battery_kwh, charging_standard, emissions_class)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		engineID, engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange, engineReq.Horsepower, engineReq.Torque,
+		engineReq.Transmission, engineReq.BatteryKWh, engineReq.ChargingStandard, engineReq.EmissionsClass)
+
 	if err != nil {
 		return models.Engine{}, err
 	}
 
-	engine := models.Engine{
-		EngineID:      engineID,
-		Displacement:  engineReq.Displacement,
-		NoOfCylinders: engineReq.NoOfCylinders,
-		CarRange:      engineReq.CarRange,
+	if err = store.SnapshotEngines(ctx, tx, "id = $1", engineID); err != nil {
+		return models.Engine{}, err
 	}
 
+	engine := engineFromRequest(engineID, engineReq)
+	engine.Version = 1
+
 	return engine, nil
 }
 
-func (e EngineStore) EngineUpdate(ctx context.Context, id string, engineReq *models.EngineRequest) (models.Engine, error) {
+// EngineUpdate replaces the engine when its current version is accepted by
+// match. Cars embed their engine, so their versions are bumped too and cached
+// car ETags stop matching. Engines of sold cars cannot be changed.
+func (e EngineStore) EngineUpdate(ctx context.Context, id string, engineReq *models.EngineRequest, match models.VersionMatch) (models.Engine, error) {
 	tracer := otel.Tracer("EngineStore")
 	ctx, span := tracer.Start(ctx, "EngineUpdate-Store")
 	defer span.End()
 
 	engineID, err := uuid.Parse(id)
 	if err != nil {
-		return models.Engine{}, fmt.Errorf("invalid UUID format: %w", err)
+		return models.Engine{}, models.Validation("invalid engine ID", models.FieldError{Field: "id", Message: "must be a valid UUID"})
 	}
 
-	tx, err := e.db.BeginTx(ctx, nil)
+	tx, err := store.BeginTx(ctx, e.db)
 	if err != nil {
 		return models.Engine{}, err
 	}
@@ -108,39 +150,80 @@ func (e EngineStore) EngineUpdate(ctx context.Context, id string, engineReq *mod
 		}
 	}()
 
-	results, err := tx.ExecContext(ctx, "UPDATE engine SET displacement = $1, no_of_cylinders = $2, car_range = $3 WHERE id = $4",
-		engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange, engineID)
+	var (
+		version int
+		before  models.EngineRequest
+	)
+	err = tx.QueryRowContext(ctx,
+		`SELECT version, displacement, no_of_cylinders, battery_kwh, charging_standard, emissions_class
+         FROM engine WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, engineID,
+	).Scan(&version, &before.Displacement, &before.NoOfCylinders, &before.BatteryKWh, &before.ChargingStandard, &before.EmissionsClass)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Engine{}, errEngineNotFound
+		}
+		return models.Engine{}, err
+	}
+	if err = match.CheckVersion("engine", version); err != nil {
+		return models.Engine{}, err
+	}
 
+	// Sold cars are final, and they would change with their engine.
+	var sold bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM car WHERE engine_id = $1 AND status = 'sold')", engineID).Scan(&sold)
 	if err != nil {
 		return models.Engine{}, err
 	}
+	if sold {
+		err = errEngineSold
+		return models.Engine{}, err
+	}
+
+	// Cars keep their fuel type, so the new spec has to suit every car using
+	// the engine.
+	if err = checkFuelTypes(ctx, tx, engineID, before, engineReq); err != nil {
+		return models.Engine{}, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE engine SET displacement = $1, no_of_cylinders = $2, car_range = $3, horsepower = $4, torque = $5, transmission = $6,
+           battery_kwh = $7, charging_standard = $8, emissions_class = $9, updated_at = NOW(), version = version + 1
+         WHERE id = $10 RETURNING version`,
+		engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange, engineReq.Horsepower, engineReq.Torque, engineReq.Transmission,
+		engineReq.BatteryKWh, engineReq.ChargingStandard, engineReq.EmissionsClass, engineID).Scan(&version)
 
-	rowsAffected, err := results.RowsAffected()
 	if err != nil {
 		return models.Engine{}, err
 	}
-	if rowsAffected == 0 {
-		return models.Engine{}, sql.ErrNoRows
+
+	_, err = tx.ExecContext(ctx, "UPDATE car SET version = version + 1 WHERE engine_id = $1", engineID)
+	if err != nil {
+		return models.Engine{}, err
 	}
 
-	updatedEngine := models.Engine{
-		EngineID:      engineID,
-		Displacement:  engineReq.Displacement,
-		NoOfCylinders: engineReq.NoOfCylinders,
-		CarRange:      engineReq.CarRange,
+	if err = store.SnapshotEngines(ctx, tx, "id = $1", engineID); err != nil {
+		return models.Engine{}, err
+	}
+	if err = store.SnapshotCars(ctx, tx, "engine_id = $1", engineID); err != nil {
+		return models.Engine{}, err
 	}
 
+	updatedEngine := engineFromRequest(engineID, engineReq)
+	updatedEngine.Version = version
+
 	return updatedEngine, nil
 }
 
-func (e EngineStore) EngineDelete(ctx context.Context, id string) (models.Engine, error) {
+// EngineDelete moves the engine to the trash. Engines still used by a live
+// car cannot be deleted.
+func (e EngineStore) EngineDelete(ctx context.Context, id, deletedBy string, match models.VersionMatch) (models.Engine, error) {
 	tracer := otel.Tracer("EngineStore")
 	ctx, span := tracer.Start(ctx, "EngineDelete-Store")
 	defer span.End()
 	
 	var engine models.Engine
 
-	tx, err := e.db.BeginTx(ctx, nil)
+	tx, err := store.BeginTx(ctx, e.db)
 	if err != nil {
 		return models.Engine{}, err
 	}
@@ -157,29 +240,138 @@ func (e EngineStore) EngineDelete(ctx context.Context, id string) (models.Engine
 		}
 	}()
 
-	err = tx.QueryRowContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range FROM engine WHERE id = $1", id).Scan(
-		&engine.EngineID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange,
-	)
+	engine, err = scanEngine(tx.QueryRowContext(ctx, "SELECT "+engineColumns+" FROM engine WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return engine, nil
+			return models.Engine{}, errEngineNotFound
 		}
-		return engine, err
+		return models.Engine{}, err
+	}
+	if err = match.CheckVersion("engine", engine.Version); err != nil {
+		return models.Engine{}, err
 	}
 
-	result, err := tx.ExecContext(ctx, "DELETE FROM engine WHERE id = $1", id)
+	var cars int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM car WHERE engine_id = $1 AND deleted_at IS NULL", id).Scan(&cars)
+	if err != nil {
+		return models.Engine{}, err
+	}
+	if cars > 0 {
+		err = models.Conflict(fmt.Sprintf("engine is used by %d car(s); delete them first or pass cascade=true", cars))
+		return models.Engine{}, err
+	}
+
+	var deletedAt time.Time
+	err = tx.QueryRowContext(ctx, "UPDATE engine SET deleted_at = NOW(), deleted_by = $2, version = version + 1 WHERE id = $1 RETURNING deleted_at, version",
+		id, deletedBy).Scan(&deletedAt, &engine.Version)
 
 	if err != nil {
 		return models.Engine{}, err
 	}
+	engine.DeletedAt = &deletedAt
+	engine.DeletedBy = deletedBy
 
-	rowsAffected, err := result.RowsAffected()
+	if err = store.SnapshotEngines(ctx, tx, "id = $1", id); err != nil {
+		return models.Engine{}, err
+	}
+
+	return engine, nil
+}
+
+// EngineRestore takes the engine out of the trash. A failed commit is
+// returned, so the engine is never reported restored when it is not.
+func (e EngineStore) EngineRestore(ctx context.Context, id string) (engine models.Engine, err error) {
+	tracer := otel.Tracer("EngineStore")
+	ctx, span := tracer.Start(ctx, "EngineRestore-Store")
+	defer span.End()
+
+	tx, err := store.BeginTx(ctx, e.db)
 	if err != nil {
 		return models.Engine{}, err
 	}
-	if rowsAffected == 0 {
-		return models.Engine{}, errors.New("no rows were deleted")
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			engine = models.Engine{}
+		}
+	}()
+
+	engine, err = scanEngine(tx.QueryRowContext(ctx, "UPDATE engine SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL RETURNING "+engineColumns, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = models.NotFound("engine not found in trash")
+		}
+		return models.Engine{}, err
 	}
 
+	if err = store.SnapshotEngines(ctx, tx, "id = $1", id); err != nil {
+		return models.Engine{}, err
+	}
 	return engine, nil
-}
\ No newline at end of file
+}
+
+// PurgeDeleted permanently removes engines deleted before cutoff. Engines
+// still referenced by a car in the trash are kept until that car is purged.
+func (e EngineStore) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	tracer := otel.Tracer("EngineStore")
+	ctx, span := tracer.Start(ctx, "PurgeDeleted-Store")
+	defer span.End()
+
+	result, err := store.Conn(ctx, e.db).ExecContext(ctx, "DELETE FROM engine e WHERE e.deleted_at < $1 AND NOT EXISTS (SELECT 1 FROM car c WHERE c.engine_id = e.id)", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func engineFromRequest(id uuid.UUID, req *models.EngineRequest) models.Engine {
+	return models.Engine{
+		EngineID:         id,
+		Displacement:     req.Displacement,
+		NoOfCylinders:    req.NoOfCylinders,
+		CarRange:         req.CarRange,
+		Horsepower:       req.Horsepower,
+		Torque:           req.Torque,
+		Transmission:     req.Transmission,
+		BatteryKWh:       req.BatteryKWh,
+		ChargingStandard: req.ChargingStandard,
+		EmissionsClass:   req.EmissionsClass,
+	}
+}
+
+// checkFuelTypes validates engineReq, which replaces before, against the fuel
+// type of every live car using the engine. Fields left as they were are not
+// rechecked; see models.FuelEngineChangeRules.
+func checkFuelTypes(ctx context.Context, tx *store.Tx, engineID uuid.UUID, before models.EngineRequest, engineReq *models.EngineRequest) error {
+	rows, err := tx.QueryContext(ctx, "SELECT DISTINCT fuel_type FROM car WHERE engine_id = $1 AND deleted_at IS NULL", engineID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var fuelTypes []string
+	for rows.Next() {
+		var fuelType string
+		if err := rows.Scan(&fuelType); err != nil {
+			return err
+		}
+		fuelTypes = append(fuelTypes, fuelType)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fuelType := range fuelTypes {
+		err := models.ValidateFuelEngineChange("engine",
+			models.FuelEngine{FuelType: fuelType, Engine: before},
+			models.FuelEngine{FuelType: fuelType, Engine: *engineReq},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
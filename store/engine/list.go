@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"go.opentelemetry.io/otel"
+)
+
+// usageQuery selects live engines with the number of live and trashed cars
+// using each; queries alias engine as e and the counts as u.
+const usageQuery = `SELECT e.id, e.displacement, e.no_of_cylinders, e.car_range, e.horsepower, e.torque, e.transmission,
+    e.battery_kwh, e.charging_standard, e.emissions_class, e.version, u.cars, u.trashed_cars
+  FROM engine e
+  CROSS JOIN LATERAL (
+    SELECT COUNT(*) FILTER (WHERE c.deleted_at IS NULL) AS cars,
+           COUNT(*) FILTER (WHERE c.deleted_at IS NOT NULL) AS trashed_cars
+    FROM car c WHERE c.engine_id = e.id
+  ) u`
+
+type sortKey struct {
+	order store.OrderBy
+	value func(models.EngineUsage) string
+}
+
+var sortKeys = map[string]sortKey{
+	"displacement": {store.OrderBy{Column: "e.displacement", Cast: "int"}, func(u models.EngineUsage) string { return strconv.FormatInt(u.Engine.Displacement, 10) }},
+	"cylinders":    {store.OrderBy{Column: "e.no_of_cylinders", Cast: "int"}, func(u models.EngineUsage) string { return strconv.Itoa(u.Engine.NoOfCylinders) }},
+	"range":        {store.OrderBy{Column: "e.car_range", Cast: "int"}, func(u models.EngineUsage) string { return strconv.FormatInt(u.Engine.CarRange, 10) }},
+	"cars":         {store.OrderBy{Column: "u.cars", Cast: "bigint"}, func(u models.EngineUsage) string { return strconv.Itoa(u.Cars) }},
+}
+
+var idSortKey = sortKey{store.OrderBy{Column: "e.id", Cast: "uuid"}, func(u models.EngineUsage) string { return u.Engine.EngineID.String() }}
+
+// engineOrdering resolves the requested sort into ordering keys, always
+// ending with e.id so the order is total and cursors are unambiguous.
+func engineOrdering(sort []models.SortField) ([]store.OrderBy, []sortKey) {
+	keys := make([]sortKey, 0, len(sort)+1)
+	for _, f := range sort {
+		key, ok := sortKeys[f.Field]
+		if !ok {
+			continue
+		}
+		key.order.Desc = f.Desc
+		keys = append(keys, key)
+	}
+	keys = append(keys, idSortKey)
+
+	order := make([]store.OrderBy, len(keys))
+	for i, k := range keys {
+		order[i] = k.order
+	}
+	return order, keys
+}
+
+// filterConditions turns the filter fields of a listing into WHERE
+// conditions, adding their values to args.
+func filterConditions(filter models.EngineFilter, args *store.Args) []string {
+	where := []string{"e.deleted_at IS NULL"}
+
+	if filter.DisplacementMin != nil {
+		where = append(where, "e.displacement >= "+args.Add(*filter.DisplacementMin))
+	}
+	if filter.DisplacementMax != nil {
+		where = append(where, "e.displacement <= "+args.Add(*filter.DisplacementMax))
+	}
+	if filter.CylindersMin != nil {
+		where = append(where, "e.no_of_cylinders >= "+args.Add(*filter.CylindersMin))
+	}
+	if filter.CylindersMax != nil {
+		where = append(where, "e.no_of_cylinders <= "+args.Add(*filter.CylindersMax))
+	}
+	if filter.RangeMin != nil {
+		where = append(where, "e.car_range >= "+args.Add(*filter.RangeMin))
+	}
+	if filter.RangeMax != nil {
+		where = append(where, "e.car_range <= "+args.Add(*filter.RangeMax))
+	}
+	if filter.CarsMin != nil {
+		where = append(where, "u.cars >= "+args.Add(*filter.CarsMin))
+	}
+	if filter.CarsMax != nil {
+		where = append(where, "u.cars <= "+args.Add(*filter.CarsMax))
+	}
+	return where
+}
+
+// ListEngines returns one page of live engines matching filter together with
+// their car counts, paginated with a keyset cursor like ListCars.
+func (e EngineStore) ListEngines(ctx context.Context, filter models.EngineFilter) (models.EngineUsagePage, error) {
+	tracer := otel.Tracer("EngineStore")
+	ctx, span := tracer.Start(ctx, "ListEngines-Store")
+	defer span.End()
+
+	var args store.Args
+	where := filterConditions(filter, &args)
+
+	order, keys := engineOrdering(filter.Sort)
+	if filter.Cursor != "" {
+		cursor, err := store.DecodeCursor(filter.Cursor, len(order))
+		if err != nil {
+			return models.EngineUsagePage{}, err
+		}
+		where = append(where, store.KeysetCondition(order, cursor, &args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > models.MaxPageSize {
+		limit = models.DefaultPageSize
+	}
+
+	query := usageQuery + " WHERE " + strings.Join(where, " AND ") +
+		" " + store.OrderClause(order) + " LIMIT " + args.Add(limit+1)
+
+	rows, err := store.Conn(ctx, e.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.EngineUsagePage{}, err
+	}
+	defer rows.Close()
+
+	page := models.EngineUsagePage{Engines: []models.EngineUsage{}}
+	for rows.Next() {
+		var u models.EngineUsage
+		err := rows.Scan(
+			&u.Engine.EngineID, &u.Engine.Displacement, &u.Engine.NoOfCylinders, &u.Engine.CarRange, &u.Engine.Horsepower, &u.Engine.Torque, &u.Engine.Transmission,
+			&u.Engine.BatteryKWh, &u.Engine.ChargingStandard, &u.Engine.EmissionsClass, &u.Engine.Version, &u.Cars, &u.TrashedCars,
+		)
+		if err != nil {
+			return models.EngineUsagePage{}, err
+		}
+		page.Engines = append(page.Engines, u)
+	}
+	if err := rows.Err(); err != nil {
+		return models.EngineUsagePage{}, err
+	}
+
+	if len(page.Engines) > limit {
+		page.Engines = page.Engines[:limit]
+		last := page.Engines[limit-1]
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = key.value(last)
+		}
+		page.NextCursor = store.EncodeCursor(values)
+	}
+	return page, nil
+}
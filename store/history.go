@@ -0,0 +1,31 @@
+package store
+
+import "context"
+
+// SnapshotCars copies the current version of the cars matching where into
+// car_history. Call it after every statement that bumps car.version, in the
+// same transaction. Versions already recorded are left alone.
+func SnapshotCars(ctx context.Context, q DBTX, where string, args ...any) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO car_history (car_id, version, name, year, brand, fuel_type, engine_id, price, status, deleted_at, deleted_by)
+         SELECT id, version, name, year, brand, fuel_type, engine_id, price, status, deleted_at, deleted_by
+         FROM car WHERE `+where+`
+         ON CONFLICT DO NOTHING`,
+		args...,
+	)
+	return err
+}
+
+// SnapshotEngines is SnapshotCars for engine_history.
+func SnapshotEngines(ctx context.Context, q DBTX, where string, args ...any) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO engine_history (engine_id, version, displacement, no_of_cylinders, car_range,
+           horsepower, torque, transmission, battery_kwh, charging_standard, emissions_class, deleted_at)
+         SELECT id, version, displacement, no_of_cylinders, car_range,
+           horsepower, torque, transmission, battery_kwh, charging_standard, emissions_class, deleted_at
+         FROM engine WHERE `+where+`
+         ON CONFLICT DO NOTHING`,
+		args...,
+	)
+	return err
+}
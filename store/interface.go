@@ -2,22 +2,118 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
 )
 
 type CarStoreInterface interface {
 	GetCarById(ctx context.Context, id string) (models.Car, error)
-	GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error)
+	GetCarForUpdate(ctx context.Context, id string) (models.Car, error)
+	ListCars(ctx context.Context, filter models.CarFilter) (models.CarPage, error)
+	SearchCars(ctx context.Context, search models.CarSearch) ([]models.CarSearchResult, error)
+	SuggestBrands(ctx context.Context, terms []string) (map[string]string, error)
+	StreamCars(ctx context.Context, filter models.CarFilter, fn func(models.Car) error) error
 	CreateCar(ctx context.Context, carReq *models.CarRequest) (models.Car, error)
-	UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (models.Car, error)
-	DeleteCar(ctx context.Context, id string) (models.Car, error)
+	CreateCars(ctx context.Context, carReqs []models.CarRequest) ([]models.Car, error)
+	UpdateCar(ctx context.Context, id string, carReq *models.CarRequest, match models.VersionMatch) (models.Car, error)
+	DeleteCar(ctx context.Context, id, deletedBy string, match models.VersionMatch) (models.Car, error)
+	RestoreCar(ctx context.Context, id string) (models.Car, error)
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error)
+	ListCarHistory(ctx context.Context, id string) ([]models.CarRevision, error)
+	SetCarStatus(ctx context.Context, id string, t models.Transition, from, changedBy, note string) (models.Car, error)
+	ListStatusChanges(ctx context.Context, id string) ([]models.CarStatusChange, error)
+	GetCarRevision(ctx context.Context, id string, version int) (models.CarRevision, error)
+	GetCarAsOf(ctx context.Context, id string, t time.Time) (models.CarRevision, error)
 }
 
 type EngineStoreInterface interface {
 
 	GetEngineById(ctx context.Context, id string) (models.Engine, error)
+	GetEngineForUpdate(ctx context.Context, id string) (models.Engine, error)
+	ListEngines(ctx context.Context, filter models.EngineFilter) (models.EngineUsagePage, error)
 	EngineCreate(ctx context.Context, engineReq *models.EngineRequest) (models.Engine, error)
-	EngineUpdate(ctx context.Context, id string, engineReq *models.EngineRequest) (models.Engine, error)
-	EngineDelete(ctx context.Context, id string) (models.Engine, error)
-}
\ No newline at end of file
+	EngineUpdate(ctx context.Context, id string, engineReq *models.EngineRequest, match models.VersionMatch) (models.Engine, error)
+	EngineDelete(ctx context.Context, id, deletedBy string, match models.VersionMatch) (models.Engine, error)
+	EngineRestore(ctx context.Context, id string) (models.Engine, error)
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type ReservationStoreInterface interface {
+	GetReservation(ctx context.Context, id string) (models.Reservation, error)
+	GetReservationForUpdate(ctx context.Context, id string) (models.Reservation, error)
+	ListReservations(ctx context.Context, carID string) ([]models.Reservation, error)
+	ListExpired(ctx context.Context, now time.Time) ([]models.Reservation, error)
+	CreateReservation(ctx context.Context, carID string, req *models.ReservationRequest, expiresAt time.Time, createdBy string) (models.Reservation, error)
+	CloseReservation(ctx context.Context, id, status, closedBy string) (models.Reservation, error)
+}
+
+type OrderStoreInterface interface {
+	GetOrder(ctx context.Context, id string) (models.Order, error)
+	GetOrderForUpdate(ctx context.Context, id string) (models.Order, error)
+	ListOrders(ctx context.Context, filter models.OrderFilter) (models.OrderPage, error)
+	CreateOrder(ctx context.Context, req *models.OrderRequest, createdBy string) (models.Order, error)
+	UpdateOrder(ctx context.Context, id string, req *models.OrderRequest) (models.Order, error)
+	SetPaymentStatus(ctx context.Context, id, paymentStatus string) (models.Order, error)
+	SetOrderStatus(ctx context.Context, id, status, changedBy string) (models.Order, error)
+}
+
+type CustomerStoreInterface interface {
+	GetCustomer(ctx context.Context, id string) (models.Customer, error)
+	GetCustomerForUpdate(ctx context.Context, id string) (models.Customer, error)
+	ListCustomers(ctx context.Context, filter models.CustomerFilter) (models.CustomerPage, error)
+	CreateCustomer(ctx context.Context, req *models.CustomerRequest, createdBy string) (models.Customer, error)
+	UpdateCustomer(ctx context.Context, id string, req *models.CustomerRequest) (models.Customer, error)
+	DeleteCustomer(ctx context.Context, id string) error
+	ListNotes(ctx context.Context, customerID string) ([]models.CustomerNote, error)
+	AddNote(ctx context.Context, customerID, body, author string) (models.CustomerNote, error)
+}
+
+type LeadStoreInterface interface {
+	GetLead(ctx context.Context, id string) (models.Lead, error)
+	GetLeadForUpdate(ctx context.Context, id string) (models.Lead, error)
+	ListLeads(ctx context.Context, filter models.LeadFilter) (models.LeadPage, error)
+	CreateLead(ctx context.Context, req *models.LeadRequest, createdBy string) (models.Lead, error)
+	UpdateLead(ctx context.Context, id string, req *models.LeadRequest) (models.Lead, error)
+	AssignLead(ctx context.Context, id, username string) (models.Lead, error)
+	DeleteLead(ctx context.Context, id string) error
+}
+
+type TestDriveStoreInterface interface {
+	GetTestDrive(ctx context.Context, id string) (models.TestDrive, error)
+	GetTestDriveForUpdate(ctx context.Context, id string) (models.TestDrive, error)
+	ListTestDrives(ctx context.Context, filter models.TestDriveFilter) ([]models.TestDrive, error)
+	CreateTestDrive(ctx context.Context, carID string, req *models.TestDriveRequest, createdBy string) (models.TestDrive, error)
+	RescheduleTestDrive(ctx context.Context, id, salesperson string, startsAt, endsAt time.Time) (models.TestDrive, error)
+	CancelTestDrive(ctx context.Context, id, cancelledBy string) (models.TestDrive, error)
+}
+
+type UserStoreInterface interface {
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	CreateUser(ctx context.Context, username, passwordHash string, role models.Role) (models.User, error)
+	UpdatePassword(ctx context.Context, username, passwordHash string) error
+	SetActive(ctx context.Context, username string, active bool) error
+	SetRole(ctx context.Context, username string, role models.Role) error
+}
+
+type SessionStoreInterface interface {
+	CreateSession(ctx context.Context, userID uuid.UUID, refresh models.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, oldHash string, next models.RefreshToken) (uuid.UUID, string, error)
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeUserSessions(ctx context.Context, username string) error
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti, sessionID string) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type AuditStoreInterface interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+	ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error)
+}
+
+// TransactorInterface runs fn in one transaction that stores called with the
+// fn context join.
+type TransactorInterface interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,244 @@
+package lead
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+var errLeadNotFound = models.NotFound("lead not found")
+
+const leadColumns = `id, customer_id, car_id, source, status, COALESCE(assigned_to, ''), assigned_at, note,
+       created_by, created_at, updated_at, version`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLead(row scanner) (models.Lead, error) {
+	var (
+		l          models.Lead
+		carID      uuid.NullUUID
+		assignedAt sql.NullTime
+	)
+	err := row.Scan(&l.ID, &l.CustomerID, &carID, &l.Source, &l.Status, &l.AssignedTo, &assignedAt, &l.Note,
+		&l.CreatedBy, &l.CreatedAt, &l.UpdatedAt, &l.Version)
+	if carID.Valid {
+		l.CarID = &carID.UUID
+	}
+	if assignedAt.Valid {
+		l.AssignedAt = &assignedAt.Time
+	}
+	return l, err
+}
+
+// leadError turns foreign key violations on customer_id and car_id into
+// validation errors on those fields.
+func leadError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23503" {
+		return err
+	}
+	field := "customer_id"
+	if strings.Contains(pqErr.Constraint, "car_id") {
+		field = "car_id"
+	}
+	return models.Validation(field+" does not exist", models.FieldError{Field: field, Rule: "exists", Message: "does not exist"})
+}
+
+// nullable stores an empty optional id as NULL.
+func nullable(id string) any {
+	if id == "" {
+		return nil
+	}
+	return id
+}
+
+type LeadStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *LeadStore {
+	return &LeadStore{db: db}
+}
+
+func (s LeadStore) GetLead(ctx context.Context, id string) (models.Lead, error) {
+	tracer := otel.Tracer("LeadStore")
+	ctx, span := tracer.Start(ctx, "GetLead-Store")
+	defer span.End()
+
+	query := "SELECT " + leadColumns + " FROM lead WHERE id = $1"
+	l, err := scanLead(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Lead{}, errLeadNotFound
+	}
+	return l, err
+}
+
+// GetLeadForUpdate reads a lead and locks its row until the surrounding
+// transaction ends.
+func (s LeadStore) GetLeadForUpdate(ctx context.Context, id string) (models.Lead, error) {
+	tracer := otel.Tracer("LeadStore")
+	ctx, span := tracer.Start(ctx, "GetLeadForUpdate-Store")
+	defer span.End()
+
+	query := "SELECT " + leadColumns + " FROM lead WHERE id = $1 FOR UPDATE"
+	l, err := scanLead(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Lead{}, errLeadNotFound
+	}
+	return l, err
+}
+
+// ListLeads returns one page of leads, newest first.
+func (s LeadStore) ListLeads(ctx context.Context, filter models.LeadFilter) (models.LeadPage, error) {
+	tracer := otel.Tracer("LeadStore")
+	ctx, span := tracer.Start(ctx, "ListLeads-Store")
+	defer span.End()
+
+	var (
+		args  store.Args
+		where = []string{"TRUE"}
+	)
+	if filter.Status != "" {
+		where = append(where, "status = "+args.Add(filter.Status))
+	}
+	if filter.AssignedTo != "" {
+		where = append(where, "assigned_to = "+args.Add(filter.AssignedTo))
+	}
+	if filter.Unassigned {
+		where = append(where, "assigned_to IS NULL")
+	}
+	if filter.CustomerID != "" {
+		where = append(where, "customer_id = "+args.Add(filter.CustomerID))
+	}
+	if filter.CarID != "" {
+		where = append(where, "car_id = "+args.Add(filter.CarID))
+	}
+
+	order := []store.OrderBy{{Column: "created_at", Cast: "timestamptz", Desc: true}, {Column: "id", Cast: "uuid", Desc: true}}
+	if filter.Cursor != "" {
+		cursor, err := store.DecodeCursor(filter.Cursor, len(order))
+		if err != nil {
+			return models.LeadPage{}, err
+		}
+		where = append(where, store.KeysetCondition(order, cursor, &args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > models.MaxPageSize {
+		limit = models.DefaultPageSize
+	}
+
+	query := "SELECT " + leadColumns + " FROM lead WHERE " + strings.Join(where, " AND ") + " " +
+		store.OrderClause(order) + " LIMIT " + args.Add(limit+1)
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.LeadPage{}, err
+	}
+	defer rows.Close()
+
+	page := models.LeadPage{Leads: []models.Lead{}}
+	for rows.Next() {
+		l, err := scanLead(rows)
+		if err != nil {
+			return models.LeadPage{}, err
+		}
+		page.Leads = append(page.Leads, l)
+	}
+	if err := rows.Err(); err != nil {
+		return models.LeadPage{}, err
+	}
+
+	if len(page.Leads) > limit {
+		page.Leads = page.Leads[:limit]
+		last := page.Leads[limit-1]
+		page.NextCursor = store.EncodeCursor([]string{last.CreatedAt.Format(time.RFC3339Nano), last.ID.String()})
+	}
+	return page, nil
+}
+
+func (s LeadStore) CreateLead(ctx context.Context, req *models.LeadRequest, createdBy string) (models.Lead, error) {
+	tracer := otel.Tracer("LeadStore")
+	ctx, span := tracer.Start(ctx, "CreateLead-Store")
+	defer span.End()
+
+	status := req.Status
+	if status == "" {
+		status = models.LeadNew
+	}
+
+	query := `INSERT INTO lead (id, customer_id, car_id, source, status, note, created_by)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)
+         RETURNING ` + leadColumns
+	l, err := scanLead(store.Conn(ctx, s.db).QueryRowContext(ctx, query,
+		uuid.New(), req.CustomerID, nullable(req.CarID), req.Source, status, req.Note, createdBy,
+	))
+	if err != nil {
+		return models.Lead{}, leadError(err)
+	}
+	return l, nil
+}
+
+// UpdateLead replaces a lead's details. The assignment is left as it is.
+func (s LeadStore) UpdateLead(ctx context.Context, id string, req *models.LeadRequest) (models.Lead, error) {
+	tracer := otel.Tracer("LeadStore")
+	ctx, span := tracer.Start(ctx, "UpdateLead-Store")
+	defer span.End()
+
+	query := `UPDATE lead SET customer_id = $2, car_id = $3, source = $4, status = $5, note = $6,
+             updated_at = NOW(), version = version + 1
+         WHERE id = $1
+         RETURNING ` + leadColumns
+	l, err := scanLead(store.Conn(ctx, s.db).QueryRowContext(ctx, query,
+		id, req.CustomerID, nullable(req.CarID), req.Source, req.Status, req.Note,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Lead{}, errLeadNotFound
+	}
+	if err != nil {
+		return models.Lead{}, leadError(err)
+	}
+	return l, nil
+}
+
+// AssignLead hands a lead to a salesperson; an empty username unassigns it.
+func (s LeadStore) AssignLead(ctx context.Context, id, username string) (models.Lead, error) {
+	tracer := otel.Tracer("LeadStore")
+	ctx, span := tracer.Start(ctx, "AssignLead-Store")
+	defer span.End()
+
+	query := `UPDATE lead SET assigned_to = $2, assigned_at = CASE WHEN $2::varchar IS NULL THEN NULL ELSE NOW() END,
+             updated_at = NOW(), version = version + 1
+         WHERE id = $1
+         RETURNING ` + leadColumns
+	l, err := scanLead(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id, nullable(username)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Lead{}, errLeadNotFound
+	}
+	return l, err
+}
+
+func (s LeadStore) DeleteLead(ctx context.Context, id string) error {
+	tracer := otel.Tracer("LeadStore")
+	ctx, span := tracer.Start(ctx, "DeleteLead-Store")
+	defer span.End()
+
+	result, err := store.Conn(ctx, s.db).ExecContext(ctx, "DELETE FROM lead WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errLeadNotFound
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+ALTER TABLE users
+  ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'viewer'
+  CHECK (role IN ('viewer', 'sales', 'inventory_manager', 'admin'));
+
+-- Akun yang sudah ada sengaja tetap 'viewer'. Hanya admin bawaan
+-- (ADMIN_USERNAME) yang dinaikkan ke 'admin' saat aplikasi start; akun lain
+-- diberi role lewat PUT /users/{username}/role.
@@ -0,0 +1,24 @@
+CREATE TABLE auth_session (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  revoked_at TIMESTAMPTZ
+);
+CREATE INDEX idx_auth_session_user ON auth_session (user_id);
+
+-- Refresh token disimpan sebagai hash SHA-256; token mentah hanya dipegang client.
+CREATE TABLE refresh_token (
+  id UUID PRIMARY KEY,
+  session_id UUID NOT NULL REFERENCES auth_session(id) ON DELETE CASCADE,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_refresh_token_session ON refresh_token (session_id);
+
+CREATE TABLE revoked_token (
+  jti UUID PRIMARY KEY,
+  expires_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
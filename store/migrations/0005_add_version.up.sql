@@ -0,0 +1,4 @@
+-- Nomor versi untuk optimistic concurrency (ETag / If-Match). Setiap UPDATE
+-- menaikkan versi, jadi klien yang memegang versi lama ditolak dengan 412.
+ALTER TABLE engine ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE car ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,15 @@
+ALTER TABLE car DROP CONSTRAINT IF EXISTS fk_engine_id;
+ALTER TABLE car
+  ADD CONSTRAINT fk_engine_id
+  FOREIGN KEY (engine_id) REFERENCES engine(id)
+  ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_engine_deleted_at;
+DROP INDEX IF EXISTS idx_car_deleted_at;
+
+ALTER TABLE car
+  DROP COLUMN IF EXISTS deleted_by,
+  DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE engine
+  DROP COLUMN IF EXISTS deleted_by,
+  DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,19 @@
+-- Soft delete: baris yang dihapus hanya ditandai, lalu dibersihkan permanen
+-- oleh job purge setelah masa retensi.
+ALTER TABLE engine
+  ADD COLUMN deleted_at TIMESTAMPTZ,
+  ADD COLUMN deleted_by VARCHAR(64);
+ALTER TABLE car
+  ADD COLUMN deleted_at TIMESTAMPTZ,
+  ADD COLUMN deleted_by VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_car_deleted_at ON car (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_engine_deleted_at ON engine (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- CASCADE dulu membuat penghapusan engine ikut menghapus semua mobil yang
+-- memakainya. Sekarang engine yang masih dipakai tidak boleh dihapus.
+ALTER TABLE car DROP CONSTRAINT IF EXISTS fk_engine_id;
+ALTER TABLE car
+  ADD CONSTRAINT fk_engine_id
+  FOREIGN KEY (engine_id) REFERENCES engine(id)
+  ON DELETE RESTRICT;
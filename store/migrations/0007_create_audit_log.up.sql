@@ -0,0 +1,16 @@
+-- Jejak audit setiap perubahan inventory. Ditulis dalam transaksi yang sama
+-- dengan perubahannya, jadi tidak ada perubahan tanpa jejak.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor VARCHAR(64) NOT NULL,
+  action VARCHAR(32) NOT NULL,
+  entity_type VARCHAR(32) NOT NULL,
+  entity_id UUID NOT NULL,
+  before JSONB,
+  after JSONB,
+  changes JSONB NOT NULL DEFAULT '{}',
+  request_id VARCHAR(128) NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity_type, entity_id, id DESC);
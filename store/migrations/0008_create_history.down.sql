@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS car_history;
+DROP TABLE IF EXISTS engine_history;
@@ -0,0 +1,41 @@
+-- Snapshot setiap versi car dan engine. Baris ditulis oleh store setiap kali
+-- versi naik, jadi isi mobil pada waktu tertentu bisa direkonstruksi.
+CREATE TABLE IF NOT EXISTS engine_history (
+  engine_id UUID NOT NULL REFERENCES engine(id) ON DELETE CASCADE,
+  version INT NOT NULL,
+  displacement INT NOT NULL,
+  no_of_cylinders INT NOT NULL,
+  car_range INT NOT NULL,
+  deleted_at TIMESTAMPTZ,
+  valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (engine_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS car_history (
+  car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+  version INT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  year VARCHAR(4) NOT NULL,
+  brand VARCHAR(255) NOT NULL,
+  fuel_type VARCHAR(50) NOT NULL,
+  engine_id UUID NOT NULL,
+  price DECIMAL(10,2) NOT NULL,
+  deleted_at TIMESTAMPTZ,
+  deleted_by VARCHAR(64),
+  valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (car_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_car_history_valid_from ON car_history (car_id, valid_from);
+CREATE INDEX IF NOT EXISTS idx_engine_history_valid_from ON engine_history (engine_id, valid_from);
+
+-- Versi saat ini dari data lama menjadi titik awal riwayat.
+INSERT INTO engine_history (engine_id, version, displacement, no_of_cylinders, car_range, deleted_at, valid_from)
+SELECT id, version, displacement, no_of_cylinders, car_range, deleted_at, COALESCE(updated_at, created_at, NOW())
+FROM engine
+ON CONFLICT DO NOTHING;
+
+INSERT INTO car_history (car_id, version, name, year, brand, fuel_type, engine_id, price, deleted_at, deleted_by, valid_from)
+SELECT id, version, name, year, brand, fuel_type, engine_id, price, deleted_at, deleted_by, COALESCE(updated_at, created_at, NOW())
+FROM car
+ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_car_engine_id;
@@ -0,0 +1,3 @@
+-- Satu engine kini bisa dipakai banyak mobil; indeks ini mempercepat daftar
+-- mobil per engine dan pengecekan sebelum engine dihapus.
+CREATE INDEX IF NOT EXISTS idx_car_engine_id ON car (engine_id);
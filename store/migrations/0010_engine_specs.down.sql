@@ -0,0 +1,15 @@
+ALTER TABLE engine_history
+  DROP COLUMN IF EXISTS emissions_class,
+  DROP COLUMN IF EXISTS charging_standard,
+  DROP COLUMN IF EXISTS battery_kwh,
+  DROP COLUMN IF EXISTS transmission,
+  DROP COLUMN IF EXISTS torque,
+  DROP COLUMN IF EXISTS horsepower;
+
+ALTER TABLE engine
+  DROP COLUMN IF EXISTS emissions_class,
+  DROP COLUMN IF EXISTS charging_standard,
+  DROP COLUMN IF EXISTS battery_kwh,
+  DROP COLUMN IF EXISTS transmission,
+  DROP COLUMN IF EXISTS torque,
+  DROP COLUMN IF EXISTS horsepower;
@@ -0,0 +1,17 @@
+-- Spesifikasi engine yang lebih lengkap, termasuk baterai untuk mobil
+-- listrik. Nilai 0 / kosong berarti belum diisi.
+ALTER TABLE engine
+  ADD COLUMN horsepower INT NOT NULL DEFAULT 0,
+  ADD COLUMN torque INT NOT NULL DEFAULT 0,
+  ADD COLUMN transmission VARCHAR(20) NOT NULL DEFAULT '',
+  ADD COLUMN battery_kwh NUMERIC(6,1) NOT NULL DEFAULT 0,
+  ADD COLUMN charging_standard VARCHAR(20) NOT NULL DEFAULT '',
+  ADD COLUMN emissions_class VARCHAR(20) NOT NULL DEFAULT '';
+
+ALTER TABLE engine_history
+  ADD COLUMN horsepower INT NOT NULL DEFAULT 0,
+  ADD COLUMN torque INT NOT NULL DEFAULT 0,
+  ADD COLUMN transmission VARCHAR(20) NOT NULL DEFAULT '',
+  ADD COLUMN battery_kwh NUMERIC(6,1) NOT NULL DEFAULT 0,
+  ADD COLUMN charging_standard VARCHAR(20) NOT NULL DEFAULT '',
+  ADD COLUMN emissions_class VARCHAR(20) NOT NULL DEFAULT '';
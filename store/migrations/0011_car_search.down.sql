@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_car_brand_trgm;
+DROP INDEX IF EXISTS idx_car_name_trgm;
+DROP INDEX IF EXISTS idx_car_search_vector;
+
+ALTER TABLE car DROP COLUMN IF EXISTS search_vector;
+
+-- Ekstensi pg_trgm sengaja tidak di-drop; bisa saja dipakai objek lain.
@@ -0,0 +1,15 @@
+-- Pencarian katalog: tsvector untuk full-text dan trigram untuk toleransi
+-- salah ketik pada nama dan merek.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE car
+  ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(brand, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(year, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(fuel_type, '')), 'C')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_car_search_vector ON car USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_car_name_trgm ON car USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_car_brand_trgm ON car USING GIN (lower(brand) gin_trgm_ops);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS car_status_change;
+
+DROP INDEX IF EXISTS idx_car_status;
+
+ALTER TABLE car_history DROP COLUMN IF EXISTS status;
+
+ALTER TABLE car
+  DROP COLUMN IF EXISTS status_changed_by,
+  DROP COLUMN IF EXISTS status_changed_at,
+  DROP COLUMN IF EXISTS status;
@@ -0,0 +1,25 @@
+-- Siklus status mobil. Transisi dicatat di car_status_change beserta siapa
+-- yang melakukannya; status terakhir juga disimpan langsung di car.
+ALTER TABLE car
+  ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'available'
+    CHECK (status IN ('in_transit', 'available', 'reserved', 'sold', 'withdrawn')),
+  ADD COLUMN status_changed_at TIMESTAMPTZ,
+  ADD COLUMN status_changed_by VARCHAR(64);
+
+ALTER TABLE car_history
+  ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'available';
+
+CREATE INDEX IF NOT EXISTS idx_car_status ON car (status) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS car_status_change (
+  id BIGSERIAL PRIMARY KEY,
+  car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+  action VARCHAR(20) NOT NULL,
+  from_status VARCHAR(20) NOT NULL,
+  to_status VARCHAR(20) NOT NULL,
+  changed_by VARCHAR(64) NOT NULL,
+  note TEXT NOT NULL DEFAULT '',
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_car_status_change_car ON car_status_change (car_id, changed_at);
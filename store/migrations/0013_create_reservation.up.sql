@@ -0,0 +1,20 @@
+-- Hold mobil untuk pelanggan dengan batas waktu. Indeks unik parsial
+-- menjamin paling banyak satu hold aktif per mobil.
+CREATE TABLE IF NOT EXISTS reservation (
+  id UUID PRIMARY KEY,
+  car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+  customer_name VARCHAR(255) NOT NULL,
+  customer_contact VARCHAR(255) NOT NULL DEFAULT '',
+  note TEXT NOT NULL DEFAULT '',
+  status VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'released', 'expired', 'fulfilled')),
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_by VARCHAR(64) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  closed_at TIMESTAMPTZ,
+  closed_by VARCHAR(64)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_reservation_active_car ON reservation (car_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_reservation_expires_at ON reservation (expires_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_reservation_car ON reservation (car_id, created_at);
@@ -0,0 +1,31 @@
+-- Order penjualan mobil. Nama tabel sales_order karena ORDER adalah kata
+-- kunci SQL. Diskon dan pajak disimpan sebagai JSONB; total dihitung ulang
+-- di aplikasi. Indeks unik parsial menjamin satu mobil hanya punya satu
+-- order yang belum dibatalkan.
+CREATE TABLE IF NOT EXISTS sales_order (
+  id UUID PRIMARY KEY,
+  number BIGSERIAL UNIQUE,
+  car_id UUID NOT NULL REFERENCES car(id),
+  customer_name VARCHAR(255) NOT NULL,
+  customer_email VARCHAR(255) NOT NULL DEFAULT '',
+  customer_phone VARCHAR(64) NOT NULL DEFAULT '',
+  customer_address TEXT NOT NULL DEFAULT '',
+  negotiated_price DECIMAL(12,2) NOT NULL CHECK (negotiated_price > 0),
+  discounts JSONB NOT NULL DEFAULT '[]',
+  trade_in_credit DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (trade_in_credit >= 0),
+  taxes JSONB NOT NULL DEFAULT '[]',
+  status VARCHAR(20) NOT NULL DEFAULT 'open'
+    CHECK (status IN ('open', 'completed', 'cancelled')),
+  payment_status VARCHAR(20) NOT NULL DEFAULT 'unpaid'
+    CHECK (payment_status IN ('unpaid', 'partially_paid', 'paid', 'refunded')),
+  note TEXT NOT NULL DEFAULT '',
+  created_by VARCHAR(64) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  completed_at TIMESTAMPTZ,
+  completed_by VARCHAR(64),
+  version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sales_order_car ON sales_order (car_id) WHERE status <> 'cancelled';
+CREATE INDEX IF NOT EXISTS idx_sales_order_status ON sales_order (status, payment_status);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS lead;
+DROP TABLE IF EXISTS customer_note;
+DROP TABLE IF EXISTS customer_interest;
+DROP TABLE IF EXISTS customer;
@@ -0,0 +1,59 @@
+-- Pelanggan beserta persetujuan kontak, mobil yang diminati dan catatan,
+-- serta lead yang dikerjakan oleh salesperson (username dari JWT).
+CREATE TABLE IF NOT EXISTS customer (
+  id UUID PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL DEFAULT '',
+  phone VARCHAR(64) NOT NULL DEFAULT '',
+  address TEXT NOT NULL DEFAULT '',
+  consent_email BOOLEAN NOT NULL DEFAULT FALSE,
+  consent_sms BOOLEAN NOT NULL DEFAULT FALSE,
+  consent_phone BOOLEAN NOT NULL DEFAULT FALSE,
+  consent_updated_at TIMESTAMPTZ,
+  created_by VARCHAR(64) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_email ON customer (lower(email)) WHERE email <> '';
+CREATE INDEX IF NOT EXISTS idx_customer_phone ON customer (phone) WHERE phone <> '';
+
+CREATE TABLE IF NOT EXISTS customer_interest (
+  customer_id UUID NOT NULL REFERENCES customer(id) ON DELETE CASCADE,
+  car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (customer_id, car_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_interest_car ON customer_interest (car_id);
+
+CREATE TABLE IF NOT EXISTS customer_note (
+  id BIGSERIAL PRIMARY KEY,
+  customer_id UUID NOT NULL REFERENCES customer(id) ON DELETE CASCADE,
+  body TEXT NOT NULL,
+  author VARCHAR(64) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_note_customer ON customer_note (customer_id, created_at);
+
+CREATE TABLE IF NOT EXISTS lead (
+  id UUID PRIMARY KEY,
+  customer_id UUID NOT NULL REFERENCES customer(id) ON DELETE CASCADE,
+  car_id UUID REFERENCES car(id) ON DELETE SET NULL,
+  source VARCHAR(20) NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'new'
+    CHECK (status IN ('new', 'contacted', 'qualified', 'won', 'lost')),
+  assigned_to VARCHAR(64),
+  assigned_at TIMESTAMPTZ,
+  note TEXT NOT NULL DEFAULT '',
+  created_by VARCHAR(64) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_lead_assigned_to ON lead (assigned_to, status);
+CREATE INDEX IF NOT EXISTS idx_lead_customer ON lead (customer_id);
+CREATE INDEX IF NOT EXISTS idx_lead_car ON lead (car_id);
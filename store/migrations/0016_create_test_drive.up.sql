@@ -0,0 +1,32 @@
+-- Jadwal test drive. Constraint EXCLUDE (butuh btree_gist untuk kolom
+-- biasa) menolak dua jadwal aktif yang bentrok untuk mobil atau salesperson
+-- yang sama; jadwal yang dibatalkan tidak ikut dicek.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE IF NOT EXISTS test_drive (
+  id UUID PRIMARY KEY,
+  car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+  customer_id UUID REFERENCES customer(id) ON DELETE SET NULL,
+  customer_name VARCHAR(255) NOT NULL,
+  customer_contact VARCHAR(255) NOT NULL DEFAULT '',
+  salesperson VARCHAR(64) NOT NULL,
+  starts_at TIMESTAMPTZ NOT NULL,
+  ends_at TIMESTAMPTZ NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'scheduled'
+    CHECK (status IN ('scheduled', 'cancelled')),
+  note TEXT NOT NULL DEFAULT '',
+  created_by VARCHAR(64) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  cancelled_at TIMESTAMPTZ,
+  cancelled_by VARCHAR(64),
+  version INTEGER NOT NULL DEFAULT 1,
+  CONSTRAINT test_drive_slot_check CHECK (ends_at > starts_at),
+  CONSTRAINT test_drive_car_overlap EXCLUDE USING gist
+    (car_id WITH =, tstzrange(starts_at, ends_at) WITH &&) WHERE (status = 'scheduled'),
+  CONSTRAINT test_drive_salesperson_overlap EXCLUDE USING gist
+    (salesperson WITH =, tstzrange(starts_at, ends_at) WITH &&) WHERE (status = 'scheduled')
+);
+
+CREATE INDEX IF NOT EXISTS idx_test_drive_car ON test_drive (car_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_test_drive_salesperson ON test_drive (salesperson, starts_at);
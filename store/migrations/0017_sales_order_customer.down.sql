@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sales_order_customer;
+
+ALTER TABLE sales_order DROP COLUMN IF EXISTS customer_id;
@@ -0,0 +1,7 @@
+-- Order bisa dikaitkan ke data customer, seperti test drive, supaya order,
+-- customer dan lead bisa di-join. Kolom nama/kontak tetap disimpan sebagai
+-- salinan untuk invoice; order lama dibiarkan tanpa customer_id.
+ALTER TABLE sales_order
+  ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customer(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sales_order_customer ON sales_order (customer_id);
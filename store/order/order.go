@@ -0,0 +1,290 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	errOrderNotFound = models.NotFound("order not found")
+	errCarHasOrder   = models.Conflict("car already has an open or completed order")
+)
+
+const orderColumns = `id, number, car_id, customer_id, customer_name, customer_email, customer_phone, customer_address,
+       negotiated_price, discounts, trade_in_credit, taxes, status, payment_status, note,
+       created_by, created_at, updated_at, completed_at, COALESCE(completed_by, ''), version`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row scanner) (models.Order, error) {
+	var (
+		o                models.Order
+		discounts, taxes []byte
+		customerID       uuid.NullUUID
+		completedAt      sql.NullTime
+	)
+	err := row.Scan(&o.ID, &o.Number, &o.CarID, &customerID, &o.Customer.Name, &o.Customer.Email, &o.Customer.Phone, &o.Customer.Address,
+		&o.NegotiatedPrice, &discounts, &o.TradeInCredit, &taxes, &o.Status, &o.PaymentStatus, &o.Note,
+		&o.CreatedBy, &o.CreatedAt, &o.UpdatedAt, &completedAt, &o.CompletedBy, &o.Version)
+	if err != nil {
+		return o, err
+	}
+	if err := json.Unmarshal(discounts, &o.Discounts); err != nil {
+		return o, err
+	}
+	if err := json.Unmarshal(taxes, &o.Taxes); err != nil {
+		return o, err
+	}
+	if customerID.Valid {
+		o.CustomerID = &customerID.UUID
+	}
+	if completedAt.Valid {
+		o.CompletedAt = &completedAt.Time
+	}
+	o.Totals = models.ComputeTotals(o.NegotiatedPrice, o.Discounts, o.Taxes, o.TradeInCredit)
+	return o, nil
+}
+
+// adjustments encodes discounts or taxes for a JSONB column, writing an
+// empty array rather than null.
+func adjustments[T any](items []T) ([]byte, error) {
+	if items == nil {
+		items = []T{}
+	}
+	return json.Marshal(items)
+}
+
+// orderError turns the one-order-per-car violation into a Conflict and an
+// unknown customer_id into a validation error.
+func orderError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch {
+	case pqErr.Code == "23505":
+		return errCarHasOrder
+	case pqErr.Code == "23503" && strings.Contains(pqErr.Constraint, "customer_id"):
+		return models.Validation("customer_id does not exist", models.FieldError{Field: "customer_id", Rule: "exists", Message: "does not exist"})
+	}
+	return err
+}
+
+// nullable stores an empty optional id as NULL.
+func nullable(id string) any {
+	if id == "" {
+		return nil
+	}
+	return id
+}
+
+type OrderStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *OrderStore {
+	return &OrderStore{db: db}
+}
+
+func (s OrderStore) GetOrder(ctx context.Context, id string) (models.Order, error) {
+	tracer := otel.Tracer("OrderStore")
+	ctx, span := tracer.Start(ctx, "GetOrder-Store")
+	defer span.End()
+
+	query := "SELECT " + orderColumns + " FROM sales_order WHERE id = $1"
+	o, err := scanOrder(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Order{}, errOrderNotFound
+	}
+	return o, err
+}
+
+// GetOrderForUpdate reads an order and locks its row until the surrounding
+// transaction ends.
+func (s OrderStore) GetOrderForUpdate(ctx context.Context, id string) (models.Order, error) {
+	tracer := otel.Tracer("OrderStore")
+	ctx, span := tracer.Start(ctx, "GetOrderForUpdate-Store")
+	defer span.End()
+
+	query := "SELECT " + orderColumns + " FROM sales_order WHERE id = $1 FOR UPDATE"
+	o, err := scanOrder(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Order{}, errOrderNotFound
+	}
+	return o, err
+}
+
+// ListOrders returns one page of orders, newest first.
+func (s OrderStore) ListOrders(ctx context.Context, filter models.OrderFilter) (models.OrderPage, error) {
+	tracer := otel.Tracer("OrderStore")
+	ctx, span := tracer.Start(ctx, "ListOrders-Store")
+	defer span.End()
+
+	var (
+		args  store.Args
+		where = []string{"TRUE"}
+	)
+	if filter.Status != "" {
+		where = append(where, "status = "+args.Add(filter.Status))
+	}
+	if filter.PaymentStatus != "" {
+		where = append(where, "payment_status = "+args.Add(filter.PaymentStatus))
+	}
+	if filter.CarID != "" {
+		where = append(where, "car_id = "+args.Add(filter.CarID))
+	}
+
+	order := []store.OrderBy{{Column: "number", Cast: "bigint", Desc: true}}
+	if filter.Cursor != "" {
+		cursor, err := store.DecodeCursor(filter.Cursor, len(order))
+		if err != nil {
+			return models.OrderPage{}, err
+		}
+		where = append(where, store.KeysetCondition(order, cursor, &args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > models.MaxPageSize {
+		limit = models.DefaultPageSize
+	}
+
+	query := "SELECT " + orderColumns + " FROM sales_order WHERE " + strings.Join(where, " AND ") + " " +
+		store.OrderClause(order) + " LIMIT " + args.Add(limit+1)
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.OrderPage{}, err
+	}
+	defer rows.Close()
+
+	page := models.OrderPage{Orders: []models.Order{}}
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return models.OrderPage{}, err
+		}
+		page.Orders = append(page.Orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return models.OrderPage{}, err
+	}
+
+	if len(page.Orders) > limit {
+		page.Orders = page.Orders[:limit]
+		page.NextCursor = store.EncodeCursor([]string{strconv.FormatInt(page.Orders[limit-1].Number, 10)})
+	}
+	return page, nil
+}
+
+// CreateOrder inserts an open order. A car can have only one order that is
+// not cancelled; a second one fails with a Conflict.
+func (s OrderStore) CreateOrder(ctx context.Context, req *models.OrderRequest, createdBy string) (models.Order, error) {
+	tracer := otel.Tracer("OrderStore")
+	ctx, span := tracer.Start(ctx, "CreateOrder-Store")
+	defer span.End()
+
+	discounts, err := adjustments(req.Discounts)
+	if err != nil {
+		return models.Order{}, err
+	}
+	taxes, err := adjustments(req.Taxes)
+	if err != nil {
+		return models.Order{}, err
+	}
+	paymentStatus := req.PaymentStatus
+	if paymentStatus == "" {
+		paymentStatus = models.PaymentUnpaid
+	}
+
+	query := `INSERT INTO sales_order (id, car_id, customer_id, customer_name, customer_email, customer_phone, customer_address,
+             negotiated_price, discounts, trade_in_credit, taxes, status, payment_status, note, created_by)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+         RETURNING ` + orderColumns
+	created, err := scanOrder(store.Conn(ctx, s.db).QueryRowContext(ctx, query,
+		uuid.New(), req.CarID, nullable(req.CustomerID), req.Customer.Name, req.Customer.Email, req.Customer.Phone, req.Customer.Address,
+		req.NegotiatedPrice, discounts, req.TradeInCredit, taxes, models.OrderOpen, paymentStatus, req.Note, createdBy,
+	))
+	if err != nil {
+		return models.Order{}, orderError(err)
+	}
+	return created, nil
+}
+
+// UpdateOrder replaces the terms of an open order.
+func (s OrderStore) UpdateOrder(ctx context.Context, id string, req *models.OrderRequest) (models.Order, error) {
+	tracer := otel.Tracer("OrderStore")
+	ctx, span := tracer.Start(ctx, "UpdateOrder-Store")
+	defer span.End()
+
+	discounts, err := adjustments(req.Discounts)
+	if err != nil {
+		return models.Order{}, err
+	}
+	taxes, err := adjustments(req.Taxes)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	query := `UPDATE sales_order SET car_id = $2, customer_name = $3, customer_email = $4, customer_phone = $5,
+             customer_address = $6, negotiated_price = $7, discounts = $8, trade_in_credit = $9, taxes = $10,
+             payment_status = $11, note = $12, customer_id = $13, updated_at = NOW(), version = version + 1
+         WHERE id = $1 AND status = 'open'
+         RETURNING ` + orderColumns
+	updated, err := scanOrder(store.Conn(ctx, s.db).QueryRowContext(ctx, query,
+		id, req.CarID, req.Customer.Name, req.Customer.Email, req.Customer.Phone, req.Customer.Address,
+		req.NegotiatedPrice, discounts, req.TradeInCredit, taxes, req.PaymentStatus, req.Note, nullable(req.CustomerID),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Order{}, errOrderNotFound
+	}
+	if err != nil {
+		return models.Order{}, orderError(err)
+	}
+	return updated, nil
+}
+
+func (s OrderStore) SetPaymentStatus(ctx context.Context, id, paymentStatus string) (models.Order, error) {
+	tracer := otel.Tracer("OrderStore")
+	ctx, span := tracer.Start(ctx, "SetPaymentStatus-Store")
+	defer span.End()
+
+	query := `UPDATE sales_order SET payment_status = $2, updated_at = NOW(), version = version + 1
+         WHERE id = $1
+         RETURNING ` + orderColumns
+	updated, err := scanOrder(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id, paymentStatus))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Order{}, errOrderNotFound
+	}
+	return updated, err
+}
+
+// SetOrderStatus closes an open order as completed or cancelled. Completion
+// also records when and by whom.
+func (s OrderStore) SetOrderStatus(ctx context.Context, id, status, changedBy string) (models.Order, error) {
+	tracer := otel.Tracer("OrderStore")
+	ctx, span := tracer.Start(ctx, "SetOrderStatus-Store")
+	defer span.End()
+
+	query := `UPDATE sales_order SET status = $2, updated_at = NOW(), version = version + 1,
+             completed_at = CASE WHEN $2 = 'completed' THEN NOW() END,
+             completed_by = CASE WHEN $2 = 'completed' THEN $3::varchar END
+         WHERE id = $1 AND status = 'open'
+         RETURNING ` + orderColumns
+	updated, err := scanOrder(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id, status, changedBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Order{}, models.Conflict("order is no longer open")
+	}
+	return updated, err
+}
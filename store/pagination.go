@@ -0,0 +1,84 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/KRAZYFLASH/carZone/models"
+)
+
+// Args collects positional query arguments while a query is being built.
+type Args []any
+
+// Add appends v and returns its placeholder ($1, $2, ...).
+func (a *Args) Add(v any) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// OrderBy is one key of a keyset-paginated ordering. Cast is the SQL type
+// cursor values are converted to before being compared with Column.
+type OrderBy struct {
+	Column string
+	Cast   string
+	Desc   bool
+}
+
+func OrderClause(order []OrderBy) string {
+	parts := make([]string, len(order))
+	for i, o := range order {
+		dir := "ASC"
+		if o.Desc {
+			dir = "DESC"
+		}
+		parts[i] = o.Column + " " + dir
+	}
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// KeysetCondition returns the predicate selecting rows that sort strictly
+// after the cursor row. Mixed directions rule out a plain row comparison, so
+// it expands to (a > x) OR (a = x AND b > y) OR ...
+func KeysetCondition(order []OrderBy, cursor []string, args *Args) string {
+	ors := make([]string, 0, len(order))
+	for i := range order {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, order[j].Column+" = "+args.Add(cursor[j])+"::"+order[j].Cast)
+		}
+		op := ">"
+		if order[i].Desc {
+			op = "<"
+		}
+		ands = append(ands, order[i].Column+" "+op+" "+args.Add(cursor[i])+"::"+order[i].Cast)
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func EncodeCursor(values []string) string {
+	raw, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor and checks it
+// carries one value per ordering key.
+func DecodeCursor(cursor string, keys int) ([]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, models.ErrInvalidCursor
+	}
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil || len(values) != keys {
+		return nil, models.ErrInvalidCursor
+	}
+	return values, nil
+}
+
+// LikePattern escapes LIKE wildcards in s and wraps it for a substring match.
+func LikePattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(s) + "%"
+}
@@ -0,0 +1,151 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	errReservationNotFound = models.NotFound("reservation not found")
+	errActiveHold          = models.Conflict("car already has an active hold")
+)
+
+const reservationColumns = `id, car_id, customer_name, customer_contact, note, status, expires_at,
+       created_by, created_at, closed_at, COALESCE(closed_by, '')`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReservation(row scanner) (models.Reservation, error) {
+	var r models.Reservation
+	var closedAt sql.NullTime
+	err := row.Scan(&r.ID, &r.CarID, &r.CustomerName, &r.CustomerContact, &r.Note, &r.Status, &r.ExpiresAt,
+		&r.CreatedBy, &r.CreatedAt, &closedAt, &r.ClosedBy)
+	if closedAt.Valid {
+		r.ClosedAt = &closedAt.Time
+	}
+	return r, err
+}
+
+type ReservationStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *ReservationStore {
+	return &ReservationStore{db: db}
+}
+
+func (s ReservationStore) GetReservation(ctx context.Context, id string) (models.Reservation, error) {
+	tracer := otel.Tracer("ReservationStore")
+	ctx, span := tracer.Start(ctx, "GetReservation-Store")
+	defer span.End()
+
+	query := "SELECT " + reservationColumns + " FROM reservation WHERE id = $1"
+	r, err := scanReservation(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Reservation{}, errReservationNotFound
+	}
+	return r, err
+}
+
+// GetReservationForUpdate reads a reservation and locks its row until the
+// surrounding transaction ends.
+func (s ReservationStore) GetReservationForUpdate(ctx context.Context, id string) (models.Reservation, error) {
+	tracer := otel.Tracer("ReservationStore")
+	ctx, span := tracer.Start(ctx, "GetReservationForUpdate-Store")
+	defer span.End()
+
+	query := "SELECT " + reservationColumns + " FROM reservation WHERE id = $1 FOR UPDATE"
+	r, err := scanReservation(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Reservation{}, errReservationNotFound
+	}
+	return r, err
+}
+
+// ListReservations returns every hold placed on a car, newest first.
+func (s ReservationStore) ListReservations(ctx context.Context, carID string) ([]models.Reservation, error) {
+	tracer := otel.Tracer("ReservationStore")
+	ctx, span := tracer.Start(ctx, "ListReservations-Store")
+	defer span.End()
+
+	query := "SELECT " + reservationColumns + " FROM reservation WHERE car_id = $1 ORDER BY created_at DESC, id"
+	return s.query(ctx, query, carID)
+}
+
+// ListExpired returns the active holds whose expiry is at or before now.
+func (s ReservationStore) ListExpired(ctx context.Context, now time.Time) ([]models.Reservation, error) {
+	tracer := otel.Tracer("ReservationStore")
+	ctx, span := tracer.Start(ctx, "ListExpired-Store")
+	defer span.End()
+
+	query := "SELECT " + reservationColumns + " FROM reservation WHERE status = 'active' AND expires_at <= $1 ORDER BY expires_at"
+	return s.query(ctx, query, now)
+}
+
+func (s ReservationStore) query(ctx context.Context, query string, args ...any) ([]models.Reservation, error) {
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []models.Reservation{}
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, r)
+	}
+	return reservations, rows.Err()
+}
+
+// CreateReservation places an active hold on a car. The partial unique index
+// on reservation(car_id) rejects a second active hold with a Conflict.
+func (s ReservationStore) CreateReservation(ctx context.Context, carID string, req *models.ReservationRequest, expiresAt time.Time, createdBy string) (models.Reservation, error) {
+	tracer := otel.Tracer("ReservationStore")
+	ctx, span := tracer.Start(ctx, "CreateReservation-Store")
+	defer span.End()
+
+	query := `INSERT INTO reservation (id, car_id, customer_name, customer_contact, note, status, expires_at, created_by)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+         RETURNING ` + reservationColumns
+	r, err := scanReservation(store.Conn(ctx, s.db).QueryRowContext(ctx, query,
+		uuid.New(), carID, req.CustomerName, req.CustomerContact, req.Note, models.ReservationActive, expiresAt, createdBy,
+	))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.Reservation{}, errActiveHold
+		}
+		return models.Reservation{}, err
+	}
+	return r, nil
+}
+
+// CloseReservation ends an active hold with status. It fails with a Conflict
+// when the hold has already ended.
+func (s ReservationStore) CloseReservation(ctx context.Context, id, status, closedBy string) (models.Reservation, error) {
+	tracer := otel.Tracer("ReservationStore")
+	ctx, span := tracer.Start(ctx, "CloseReservation-Store")
+	defer span.End()
+
+	query := `UPDATE reservation SET status = $2, closed_at = NOW(), closed_by = $3
+         WHERE id = $1 AND status = 'active'
+         RETURNING ` + reservationColumns
+	r, err := scanReservation(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id, status, closedBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Reservation{}, models.Conflict("reservation is no longer active")
+	}
+	return r, err
+}
@@ -0,0 +1,188 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *SessionStore {
+	return &SessionStore{db: db}
+}
+
+// CreateSession opens a new login session for userID together with its first
+// refresh token.
+func (s SessionStore) CreateSession(ctx context.Context, userID uuid.UUID, refresh models.RefreshToken) error {
+	tracer := otel.Tracer("SessionStore")
+	ctx, span := tracer.Start(ctx, "CreateSession-Store")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO auth_session (id, user_id) VALUES ($1, $2)`, refresh.SessionID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO refresh_token (id, session_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		refresh.ID, refresh.SessionID, refresh.Hash, refresh.ExpiresAt,
+	)
+	return err
+}
+
+// RotateRefreshToken consumes the refresh token with oldHash and stores next
+// in the same session, returning the session ID and its owner's username.
+// Presenting a token that was already used revokes the whole session.
+func (s SessionStore) RotateRefreshToken(ctx context.Context, oldHash string, next models.RefreshToken) (uuid.UUID, string, error) {
+	tracer := otel.Tracer("SessionStore")
+	ctx, span := tracer.Start(ctx, "RotateRefreshToken-Store")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	var reused bool
+	defer func() {
+		// Reuse tetap di-commit supaya pencabutan sesi tersimpan.
+		if err != nil && !reused {
+			_ = tx.Rollback()
+			return
+		}
+		if cmErr := tx.Commit(); cmErr != nil {
+			err = cmErr
+		}
+	}()
+
+	var (
+		tokenID   uuid.UUID
+		sessionID uuid.UUID
+		username  string
+		expiresAt time.Time
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+SELECT rt.id, rt.session_id, u.username, rt.expires_at, rt.used_at, s.revoked_at
+FROM refresh_token rt
+JOIN auth_session s ON s.id = rt.session_id
+JOIN users u ON u.id = s.user_id
+WHERE rt.token_hash = $1
+FOR UPDATE OF rt, s`, oldHash).Scan(&tokenID, &sessionID, &username, &expiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = models.ErrInvalidRefreshToken
+		}
+		return uuid.Nil, "", err
+	}
+
+	if revokedAt.Valid || time.Now().After(expiresAt) {
+		err = models.ErrInvalidRefreshToken
+		return uuid.Nil, "", err
+	}
+	if usedAt.Valid {
+		reused = true
+		if _, err = tx.ExecContext(ctx, `UPDATE auth_session SET revoked_at = now() WHERE id = $1`, sessionID); err != nil {
+			return uuid.Nil, "", err
+		}
+		err = models.ErrRefreshTokenReused
+		return uuid.Nil, "", err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE refresh_token SET used_at = now() WHERE id = $1`, tokenID); err != nil {
+		return uuid.Nil, "", err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO refresh_token (id, session_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		next.ID, sessionID, next.Hash, next.ExpiresAt,
+	)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return sessionID, username, nil
+}
+
+func (s SessionStore) RevokeSession(ctx context.Context, sessionID string) error {
+	tracer := otel.Tracer("SessionStore")
+	ctx, span := tracer.Start(ctx, "RevokeSession-Store")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE auth_session SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, sessionID)
+	return err
+}
+
+func (s SessionStore) RevokeUserSessions(ctx context.Context, username string) error {
+	tracer := otel.Tracer("SessionStore")
+	ctx, span := tracer.Start(ctx, "RevokeUserSessions-Store")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, `
+UPDATE auth_session SET revoked_at = now()
+WHERE revoked_at IS NULL AND user_id = (SELECT id FROM users WHERE username = $1)`, username)
+	return err
+}
+
+func (s SessionStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	tracer := otel.Tracer("SessionStore")
+	ctx, span := tracer.Start(ctx, "RevokeToken-Store")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO revoked_token (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`, jti, expiresAt)
+	return err
+}
+
+// IsRevoked reports whether the access token jti was revoked directly or
+// belongs to a revoked session.
+func (s SessionStore) IsRevoked(ctx context.Context, jti, sessionID string) (bool, error) {
+	tracer := otel.Tracer("SessionStore")
+	ctx, span := tracer.Start(ctx, "IsRevoked-Store")
+	defer span.End()
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, `
+SELECT EXISTS (SELECT 1 FROM revoked_token WHERE jti = $1)
+    OR NOT EXISTS (SELECT 1 FROM auth_session WHERE id = $2 AND revoked_at IS NULL)`,
+		jti, sessionID,
+	).Scan(&revoked)
+	return revoked, err
+}
+
+// DeleteExpired removes revocation entries and refresh tokens that can no
+// longer be presented, and sessions left without any refresh token.
+func (s SessionStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	tracer := otel.Tracer("SessionStore")
+	ctx, span := tracer.Start(ctx, "DeleteExpired-Store")
+	defer span.End()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM revoked_token WHERE expires_at < $1`, now); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM refresh_token WHERE expires_at < $1`, now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+DELETE FROM auth_session s
+WHERE NOT EXISTS (SELECT 1 FROM refresh_token rt WHERE rt.session_id = s.id)`)
+	return err
+}
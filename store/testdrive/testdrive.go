@@ -0,0 +1,225 @@
+package testdrive
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/KRAZYFLASH/carZone/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	errTestDriveNotFound   = models.NotFound("test drive not found")
+	errCarBooked           = models.Conflict("car is already booked for an overlapping slot")
+	errSalespersonBooked   = models.Conflict("salesperson already has a test drive in an overlapping slot")
+	errTestDriveNotPending = models.Conflict("test drive is no longer scheduled")
+)
+
+// testDriveColumns is the select list read by scanTestDrive; queries alias
+// test_drive as td and join its car as c.
+const testDriveColumns = `td.id, td.car_id, c.brand || ' ' || c.name || ' ' || c.year, td.customer_id, td.customer_name,
+       td.customer_contact, td.salesperson, td.starts_at, td.ends_at, td.status, td.note, td.created_by, td.created_at,
+       td.updated_at, td.cancelled_at, COALESCE(td.cancelled_by, ''), td.version`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTestDrive(row scanner) (models.TestDrive, error) {
+	var (
+		d           models.TestDrive
+		customerID  uuid.NullUUID
+		cancelledAt sql.NullTime
+	)
+	err := row.Scan(&d.ID, &d.CarID, &d.Car, &customerID, &d.CustomerName,
+		&d.CustomerContact, &d.Salesperson, &d.StartsAt, &d.EndsAt, &d.Status, &d.Note, &d.CreatedBy, &d.CreatedAt,
+		&d.UpdatedAt, &cancelledAt, &d.CancelledBy, &d.Version)
+	if customerID.Valid {
+		d.CustomerID = &customerID.UUID
+	}
+	if cancelledAt.Valid {
+		d.CancelledAt = &cancelledAt.Time
+	}
+	return d, err
+}
+
+// testDriveError turns exclusion violations into a Conflict naming what is
+// double-booked and an unknown customer_id into a validation error.
+func testDriveError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch {
+	case pqErr.Code == "23P01" && pqErr.Constraint == "test_drive_salesperson_overlap":
+		return errSalespersonBooked
+	case pqErr.Code == "23P01":
+		return errCarBooked
+	case pqErr.Code == "23503" && strings.Contains(pqErr.Constraint, "customer_id"):
+		return models.Validation("customer_id does not exist", models.FieldError{Field: "customer_id", Rule: "exists", Message: "does not exist"})
+	}
+	return err
+}
+
+// nullable stores an empty optional id as NULL.
+func nullable(id string) any {
+	if id == "" {
+		return nil
+	}
+	return id
+}
+
+type TestDriveStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *TestDriveStore {
+	return &TestDriveStore{db: db}
+}
+
+func (s TestDriveStore) GetTestDrive(ctx context.Context, id string) (models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveStore")
+	ctx, span := tracer.Start(ctx, "GetTestDrive-Store")
+	defer span.End()
+
+	query := "SELECT " + testDriveColumns + " FROM test_drive td JOIN car c ON c.id = td.car_id WHERE td.id = $1"
+	d, err := scanTestDrive(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TestDrive{}, errTestDriveNotFound
+	}
+	return d, err
+}
+
+// GetTestDriveForUpdate reads a test drive and locks its row until the
+// surrounding transaction ends.
+func (s TestDriveStore) GetTestDriveForUpdate(ctx context.Context, id string) (models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveStore")
+	ctx, span := tracer.Start(ctx, "GetTestDriveForUpdate-Store")
+	defer span.End()
+
+	query := "SELECT " + testDriveColumns + " FROM test_drive td JOIN car c ON c.id = td.car_id WHERE td.id = $1 FOR UPDATE OF td"
+	d, err := scanTestDrive(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TestDrive{}, errTestDriveNotFound
+	}
+	return d, err
+}
+
+// ListTestDrives returns the test drives overlapping the filter's window,
+// earliest first.
+func (s TestDriveStore) ListTestDrives(ctx context.Context, filter models.TestDriveFilter) ([]models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveStore")
+	ctx, span := tracer.Start(ctx, "ListTestDrives-Store")
+	defer span.End()
+
+	var (
+		args  store.Args
+		where = []string{"td.ends_at > " + args.Add(filter.From)}
+	)
+	if !filter.To.IsZero() {
+		where = append(where, "td.starts_at < "+args.Add(filter.To))
+	}
+	if filter.CarID != "" {
+		where = append(where, "td.car_id = "+args.Add(filter.CarID))
+	}
+	if filter.Salesperson != "" {
+		where = append(where, "td.salesperson = "+args.Add(filter.Salesperson))
+	}
+	if filter.Status != "" {
+		where = append(where, "td.status = "+args.Add(filter.Status))
+	}
+
+	query := "SELECT " + testDriveColumns + " FROM test_drive td JOIN car c ON c.id = td.car_id WHERE " +
+		strings.Join(where, " AND ") + " ORDER BY td.starts_at, td.id"
+
+	rows, err := store.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drives := []models.TestDrive{}
+	for rows.Next() {
+		d, err := scanTestDrive(rows)
+		if err != nil {
+			return nil, err
+		}
+		drives = append(drives, d)
+	}
+	return drives, rows.Err()
+}
+
+// CreateTestDrive books a slot. The exclusion constraints reject a slot that
+// overlaps a scheduled drive of the same car or salesperson with a Conflict.
+func (s TestDriveStore) CreateTestDrive(ctx context.Context, carID string, req *models.TestDriveRequest, createdBy string) (models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveStore")
+	ctx, span := tracer.Start(ctx, "CreateTestDrive-Store")
+	defer span.End()
+
+	query := `WITH td AS (
+             INSERT INTO test_drive (id, car_id, customer_id, customer_name, customer_contact, salesperson,
+                                     starts_at, ends_at, status, note, created_by)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+             RETURNING *
+         )
+         SELECT ` + testDriveColumns + ` FROM td JOIN car c ON c.id = td.car_id`
+	d, err := scanTestDrive(store.Conn(ctx, s.db).QueryRowContext(ctx, query,
+		uuid.New(), carID, nullable(req.CustomerID), req.CustomerName, req.CustomerContact, req.Salesperson,
+		req.StartsAt, req.EndsAt, models.TestDriveScheduled, req.Note, createdBy,
+	))
+	if err != nil {
+		return models.TestDrive{}, testDriveError(err)
+	}
+	return d, nil
+}
+
+// RescheduleTestDrive moves a scheduled drive to another slot and
+// salesperson, subject to the same overlap checks as a new booking.
+func (s TestDriveStore) RescheduleTestDrive(ctx context.Context, id, salesperson string, startsAt, endsAt time.Time) (models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveStore")
+	ctx, span := tracer.Start(ctx, "RescheduleTestDrive-Store")
+	defer span.End()
+
+	query := `WITH td AS (
+             UPDATE test_drive SET salesperson = $2, starts_at = $3, ends_at = $4,
+                 updated_at = NOW(), version = version + 1
+             WHERE id = $1 AND status = 'scheduled'
+             RETURNING *
+         )
+         SELECT ` + testDriveColumns + ` FROM td JOIN car c ON c.id = td.car_id`
+	d, err := scanTestDrive(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id, salesperson, startsAt, endsAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TestDrive{}, errTestDriveNotPending
+	}
+	if err != nil {
+		return models.TestDrive{}, testDriveError(err)
+	}
+	return d, nil
+}
+
+// CancelTestDrive cancels a scheduled drive, freeing its slot. It fails with
+// a Conflict when the drive is already cancelled.
+func (s TestDriveStore) CancelTestDrive(ctx context.Context, id, cancelledBy string) (models.TestDrive, error) {
+	tracer := otel.Tracer("TestDriveStore")
+	ctx, span := tracer.Start(ctx, "CancelTestDrive-Store")
+	defer span.End()
+
+	query := `WITH td AS (
+             UPDATE test_drive SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $2,
+                 updated_at = NOW(), version = version + 1
+             WHERE id = $1 AND status = 'scheduled'
+             RETURNING *
+         )
+         SELECT ` + testDriveColumns + ` FROM td JOIN car c ON c.id = td.car_id`
+	d, err := scanTestDrive(store.Conn(ctx, s.db).QueryRowContext(ctx, query, id, cancelledBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TestDrive{}, errTestDriveNotPending
+	}
+	return d, err
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+// DBTX is the query surface shared by *sql.DB and *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txCtxKey struct{}
+
+// txState is the transaction carried in a context, shared by every nested
+// WithTx so savepoint names stay unique.
+type txState struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+func txFromContext(ctx context.Context) (*txState, bool) {
+	st, ok := ctx.Value(txCtxKey{}).(*txState)
+	return st, ok
+}
+
+// Transactor runs a unit of work in one database transaction. The
+// transaction travels in the context, so every store called with that
+// context joins it instead of opening its own.
+type Transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithTx runs fn in a transaction and commits when fn returns nil. When ctx
+// already carries a transaction, fn runs inside a SAVEPOINT instead: an error
+// undoes only fn's work and the outer transaction stays usable.
+func (t *Transactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if st, ok := txFromContext(ctx); ok {
+		return st.savepoint(ctx, fn)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	return fn(context.WithValue(ctx, txCtxKey{}, &txState{tx: tx}))
+}
+
+func (st *txState) savepoint(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	st.savepoints++
+	name := "sp_" + strconv.Itoa(st.savepoints)
+	if _, err := st.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+		if err != nil {
+			_, _ = st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			return
+		}
+		_, err = st.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	}()
+
+	return fn(ctx)
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none.
+func Conn(ctx context.Context, db *sql.DB) DBTX {
+	if st, ok := txFromContext(ctx); ok {
+		return st.tx
+	}
+	return db
+}
+
+// Tx is a store-level transaction. When ctx already carries a transaction
+// the Tx joins it and Commit and Rollback are no-ops, leaving the outcome to
+// the caller that opened it.
+type Tx struct {
+	*sql.Tx
+	joined bool
+}
+
+// BeginTx starts a transaction, or joins the one carried by ctx.
+func BeginTx(ctx context.Context, db *sql.DB) (*Tx, error) {
+	if st, ok := txFromContext(ctx); ok {
+		return &Tx{Tx: st.tx, joined: true}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+func (t *Tx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *Tx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,127 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KRAZYFLASH/carZone/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+type UserStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *UserStore {
+	return &UserStore{db: db}
+}
+
+func (s UserStore) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "GetUserByUsername-Store")
+	defer span.End()
+
+	var user models.User
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, username, password_hash, role, active, created_at, updated_at FROM users WHERE username = $1`,
+		username,
+	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Active, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, models.ErrUserNotFound
+		}
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (s UserStore) CreateUser(ctx context.Context, username, passwordHash string, role models.Role) (models.User, error) {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "CreateUser-Store")
+	defer span.End()
+
+	now := time.Now()
+	user := models.User{
+		ID:           uuid.New(),
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         role,
+		Active:       true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (id, username, password_hash, role, active, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		user.ID, user.Username, user.PasswordHash, user.Role, user.Active, user.CreatedAt, user.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.User{}, models.ErrUserExists
+		}
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (s UserStore) UpdatePassword(ctx context.Context, username, passwordHash string) error {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "UpdatePassword-Store")
+	defer span.End()
+
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET password_hash = $1, updated_at = $2 WHERE username = $3`,
+		passwordHash, time.Now(), username,
+	)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+func (s UserStore) SetActive(ctx context.Context, username string, active bool) error {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "SetActive-Store")
+	defer span.End()
+
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET active = $1, updated_at = $2 WHERE username = $3`,
+		active, time.Now(), username,
+	)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+func (s UserStore) SetRole(ctx context.Context, username string, role models.Role) error {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "SetRole-Store")
+	defer span.End()
+
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET role = $1, updated_at = $2 WHERE username = $3`,
+		role, time.Now(), username,
+	)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+func expectOneRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}